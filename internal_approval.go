@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeRequest asks a second user to approve a plan before it is applied to
+// a deployment in one of the approval_zones. The pending area of a
+// deployment (.launcher/pending) holds request.yaml, the proposed tfvars and
+// the saved plan; only that plan is applied once approved.
+type ChangeRequest struct {
+	ID          string `yaml:"id"` // <action>_<timestamp>, like run logs
+	Deployment  string `yaml:"deployment"`
+	Action      string `yaml:"action"`       // create or apply
+	RequestedBy string `yaml:"requested_by"` // OS user, see osUser
+	RequestedAt string `yaml:"requested_at"`
+	Status      string `yaml:"status"` // requestPending, requestApproved, ...
+	ReviewedBy  string `yaml:"reviewed_by,omitempty"`
+	ReviewedAt  string `yaml:"reviewed_at,omitempty"`
+	Plan        string `yaml:"plan"` // rendered plan summary
+}
+
+// Change request states.
+const (
+	requestPending    = "pending"
+	requestApproved   = "approved"
+	requestRejected   = "rejected"
+	requestApplied    = "applied"
+	requestSuperseded = "superseded" // replaced by a newer request
+)
+
+const (
+	proposedTfvars = "proposed.tfvars"
+	pendingPlan    = "change.tfplan"
+)
+
+func pendingDir(depDir string) string {
+	return filepath.Join(depDir, ".launcher", "pending")
+}
+
+// approvalArchiveDir keeps the requests that were applied or superseded.
+func approvalArchiveDir(depDir string) string {
+	return filepath.Join(depDir, ".launcher", "approvals")
+}
+
+// deploymentZones returns the zones a deployment is in or was applied to: the
+// zone in terraform.tfvars, the one of the last applied version and the one
+// its app dir is named after (<provider>_<app>_<zone>_<platform_id>).
+func deploymentZones(depDir string) []string {
+	var zones []string
+	if vals, err := loadTfvars(filepath.Join(depDir, "terraform.tfvars")); err == nil {
+		zones = append(zones, tfvarsFormValue(vals["zone"]))
+	}
+	if entries, err := readHistory(depDir); err == nil {
+		if e, ok := lastApplied(entries); ok {
+			if zone, err := historyZone(depDir, e); err == nil {
+				zones = append(zones, zone)
+			}
+		}
+	}
+	if parts := strings.Split(filepath.Base(depDir), "_"); len(parts) >= 4 {
+		zones = append(zones, parts[len(parts)-2])
+	}
+	return zones
+}
+
+// requiresApproval reports whether changes to a deployment need a second
+// user's approval because of its zone. Any of deploymentZones counts, so a
+// zone edited in terraform.tfvars doesn't take a deployment out of approval.
+func requiresApproval(cfg Config, depDir string) bool {
+	for _, zone := range deploymentZones(depDir) {
+		if indexOf(zone, cfg.ApprovalZones) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkZoneChange refuses to set the zone in terraform.tfvars into or out of
+// an approval zone, whether by editing or reverting it; like renames, such
+// moves can't be approved.
+func checkZoneChange(cfg Config, depDir, zone string) error {
+	vals, err := loadTfvars(filepath.Join(depDir, "terraform.tfvars"))
+	if err != nil {
+		return err
+	}
+	current := tfvarsFormValue(vals["zone"])
+	if zone == current {
+		return nil
+	}
+	if requiresApproval(cfg, depDir) || indexOf(zone, cfg.ApprovalZones) >= 0 {
+		return fmt.Errorf("moving '%s' from %s to %s needs approval; zone changes can't be approved, create the deployment in the new zone instead", filepath.Base(depDir), current, zone)
+	}
+	return nil
+}
+
+// readChangeRequest returns the open request of a deployment, or nil.
+func readChangeRequest(depDir string) (*ChangeRequest, error) {
+	data, err := os.ReadFile(filepath.Join(pendingDir(depDir), "request.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var req ChangeRequest
+	if err := yaml.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func writeChangeRequest(dir string, req ChangeRequest) error {
+	data, err := yaml.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "request.yaml"), data, 0644)
+}
+
+// archiveChangeRequest moves the request record out of the pending area and
+// removes the pending area.
+func archiveChangeRequest(depDir string, req ChangeRequest) error {
+	dir := approvalArchiveDir(depDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, req.ID+".yaml"), data, 0644); err != nil {
+		return err
+	}
+	return os.RemoveAll(pendingDir(depDir))
+}
+
+// requestApproval saves a plan of the deployment's current tfvars in the
+// pending area and records a request for approval. An open request is
+// superseded. The plan is returned for the audit log.
+func requestApproval(ctx context.Context, tf TerraformRunner, depDir, action string) (string, PlanSummary, error) {
+	name := filepath.Base(depDir)
+	if old, err := readChangeRequest(depDir); err != nil {
+		return "", PlanSummary{}, err
+	} else if old != nil {
+		old.Status = requestSuperseded
+		if err := archiveChangeRequest(depDir, *old); err != nil {
+			return "", PlanSummary{}, err
+		}
+	}
+	dir := pendingDir(depDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", PlanSummary{}, err
+	}
+	tfvars, err := os.ReadFile(filepath.Join(depDir, "terraform.tfvars"))
+	if err != nil {
+		return "", PlanSummary{}, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, proposedTfvars), tfvars, 0644); err != nil {
+		return "", PlanSummary{}, err
+	}
+	if err := runTerraformInit(ctx, tf, depDir); err != nil {
+		return "", PlanSummary{}, err
+	}
+	planFile, err := filepath.Rel(depDir, filepath.Join(dir, pendingPlan))
+	if err != nil {
+		return "", PlanSummary{}, err
+	}
+	out, err := tf.Run(ctx, depDir, "plan", "-input=false", "-out="+planFile)
+	if err != nil {
+		return "", PlanSummary{}, fmt.Errorf("terraform plan failed: %v\n%s", err, tailLines(ansi.Strip(string(out)), 3))
+	}
+	plan, err := showPlan(ctx, tf, depDir, planFile)
+	if err != nil {
+		return "", PlanSummary{}, err
+	}
+	now := time.Now().UTC()
+	req := ChangeRequest{
+		ID:          fmt.Sprintf("%s_%s", action, now.Format(logTimeFormat)),
+		Deployment:  name,
+		Action:      action,
+		RequestedBy: osUser(),
+		RequestedAt: now.Format(time.RFC3339),
+		Status:      requestPending,
+		Plan:        renderPlanSummary(plan),
+	}
+	if err := writeChangeRequest(dir, req); err != nil {
+		return "", PlanSummary{}, err
+	}
+	if _, err := recordTfvars(depDir, "request", false); err != nil {
+		return "", PlanSummary{}, err
+	}
+	return fmt.Sprintf("Approval requested for '%s' (%d to add, %d to change, %d to replace, %d to destroy); another user must approve it under [W] Approvals.",
+		name, plan.Count("create"), plan.Count("update"), plan.Count("replace"), plan.Count("delete")), plan, nil
+}
+
+// reviewChangeRequest approves or rejects the open request of a deployment.
+// Nobody reviews their own request; both sides are compared by OS user.
+func reviewChangeRequest(depDir string, approve bool) (string, error) {
+	req, err := readChangeRequest(depDir)
+	if err != nil {
+		return "", err
+	}
+	if req == nil || req.Status != requestPending {
+		return "", fmt.Errorf("no pending request for '%s'", filepath.Base(depDir))
+	}
+	user := osUser()
+	if user == "" {
+		return "", fmt.Errorf("could not determine the OS user; requests can't be reviewed")
+	}
+	if user == req.RequestedBy {
+		return "", fmt.Errorf("%s requested this change and can't review it; another user must", user)
+	}
+	req.Status, req.ReviewedBy, req.ReviewedAt = requestRejected, user, time.Now().UTC().Format(time.RFC3339)
+	action := "reject"
+	if approve {
+		req.Status, action = requestApproved, "approve"
+	}
+	if err := writeChangeRequest(pendingDir(depDir), *req); err != nil {
+		return "", err
+	}
+	if _, err := recordTfvars(depDir, action, false); err != nil {
+		return "", err
+	}
+	if !approve {
+		if err := archiveChangeRequest(depDir, *req); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Request %s for '%s' rejected.", req.ID, req.Deployment), nil
+	}
+	return fmt.Sprintf("Request %s for '%s' approved by %s; it can be applied now.", req.ID, req.Deployment, user), nil
+}
+
+// applyApproved applies the saved plan of an approved request. It refuses
+// if terraform.tfvars changed since the request.
+func applyApproved(ctx context.Context, cfg Config, tf TerraformRunner, depDir string) terraformDoneMsg {
+	req, err := readChangeRequest(depDir)
+	if err != nil {
+		return terraformDoneMsg{err: err}
+	}
+	if req == nil || req.Status != requestApproved {
+		return terraformDoneMsg{err: fmt.Errorf("'%s' has no approved request; only approved plans can be applied", filepath.Base(depDir))}
+	}
+	current, err := os.ReadFile(filepath.Join(depDir, "terraform.tfvars"))
+	if err != nil {
+		return terraformDoneMsg{err: err}
+	}
+	proposed, err := os.ReadFile(filepath.Join(pendingDir(depDir), proposedTfvars))
+	if err != nil || !bytes.Equal(current, proposed) {
+		return terraformDoneMsg{err: fmt.Errorf("terraform.tfvars changed since request %s was made; request approval again", req.ID)}
+	}
+	note, err := snapshotBeforeApply(cfg, depDir)
+	if err != nil {
+		return terraformDoneMsg{err: err}
+	}
+	planFile, err := filepath.Rel(depDir, filepath.Join(pendingDir(depDir), pendingPlan))
+	if err != nil {
+		return terraformDoneMsg{err: err}
+	}
+	if err := runTerraformInit(ctx, tf, depDir); err != nil {
+		return terraformDoneMsg{err: err}
+	}
+	plan, err := showPlan(ctx, tf, depDir, planFile)
+	if err != nil {
+		return terraformDoneMsg{err: err}
+	}
+	out, err := tf.Run(ctx, depDir, "apply", "-input=false", planFile)
+	if err != nil {
+		return terraformDoneMsg{plan: plan, err: fmt.Errorf("terraform apply of the approved plan failed: %v\n%s", err, tailLines(ansi.Strip(string(out)), 3))}
+	}
+	if err := setDeploymentState(depDir, "DEPLOYED", "apply"); err != nil {
+		return terraformDoneMsg{plan: plan, err: err}
+	}
+	req.Status = requestApplied
+	if err := archiveChangeRequest(depDir, *req); err != nil {
+		note += " Request not archived: " + err.Error()
+	}
+	if _, err := recordTfvars(depDir, "apply", true); err != nil {
+		note += " History not recorded: " + err.Error()
+	}
+	if _, err := runTerraformOutput(ctx, tf, depDir); err != nil {
+		note += " Outputs not refreshed: " + err.Error()
+	}
+	return terraformDoneMsg{result: fmt.Sprintf("Approved request %s applied.%s", req.ID, note), plan: plan}
+}
+
+// pendingChange is an open request with its deployment.
+type pendingChange struct {
+	Dep deploymentInfo
+	Req ChangeRequest
+}
+
+// listChangeRequests returns the open requests of all deployments.
+func listChangeRequests(infos []deploymentInfo) []pendingChange {
+	var out []pendingChange
+	for _, dep := range infos {
+		if req, err := readChangeRequest(dep.Path); err == nil && req != nil {
+			out = append(out, pendingChange{Dep: dep, Req: *req})
+		}
+	}
+	return out
+}
+
+// changeRequestReview renders what a reviewer needs: the tfvars diff from the
+// last applied version (empty for new deployments) to the proposal, and the plan.
+func changeRequestReview(c pendingChange) string {
+	proposed, err := os.ReadFile(filepath.Join(pendingDir(c.Dep.Path), proposedTfvars))
+	if err != nil {
+		return "Proposed tfvars missing: " + err.Error()
+	}
+	base, baseName := "", "(nothing applied)"
+	entries, _ := readHistory(c.Dep.Path)
+	if applied, ok := lastApplied(entries); ok {
+		base, _ = readHistoryVersion(c.Dep.Path, applied)
+		baseName = fmt.Sprintf("v%d (applied %s by %s)", applied.Version, applied.Time, applied.User)
+	}
+	diff := unifiedDiff(baseName, "proposed ("+c.Req.ID+")", base, string(proposed))
+	if diff == "" {
+		diff = "No tfvars changes since the last apply.\n"
+	}
+	return fmt.Sprintf("Requested by %s at %s\n\n%s\n%s", c.Req.RequestedBy, c.Req.RequestedAt, diff, c.Req.Plan)
+}
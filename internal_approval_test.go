@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequiresApproval(t *testing.T) {
+	cfg := Config{ApprovalZones: []string{"dmz"}}
+	write := func(t *testing.T, dir, zone string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte("zone = \""+zone+"\"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newDep := func(t *testing.T, name, zone string) string {
+		t.Helper()
+		dir := filepath.Join(t.TempDir(), name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		write(t, dir, zone)
+		return dir
+	}
+
+	dir := newDep(t, "pve_web_lan_p1", "lan")
+	if requiresApproval(cfg, dir) {
+		t.Error("lan deployment requires approval")
+	}
+	if err := checkZoneChange(cfg, dir, "lab"); err != nil {
+		t.Errorf("lan → lab refused: %v", err)
+	}
+	if err := checkZoneChange(cfg, dir, "dmz"); err == nil {
+		t.Error("lan → dmz allowed")
+	}
+
+	// The app dir name keeps the zone it was created in.
+	dir = newDep(t, "pve_web_dmz_p1", "lan")
+	if !requiresApproval(cfg, dir) {
+		t.Error("deployment named after dmz doesn't require approval")
+	}
+
+	// So does the last applied version.
+	dir = newDep(t, "pve_web_lan_p2", "dmz")
+	if _, err := recordTfvars(dir, "apply", true); err != nil {
+		t.Fatal(err)
+	}
+	write(t, dir, "lan")
+	if _, err := recordTfvars(dir, "edit", false); err != nil {
+		t.Fatal(err)
+	}
+	if !requiresApproval(cfg, dir) {
+		t.Error("deployment last applied in dmz doesn't require approval")
+	}
+	if err := checkZoneChange(cfg, dir, "lab"); err == nil {
+		t.Error("zone change of a deployment last applied in dmz allowed")
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AuditEntry is one line of the append-only audit log (JSON Lines). Entries
+// are only ever appended; the viewer and exports read the whole file.
+type AuditEntry struct {
+	Time        string        `json:"time"`
+	User        string        `json:"user"`                   // OS user, see osUser
+	DisplayUser string        `json:"display_user,omitempty"` // LAUNCHER_USER, if set to another name
+	Host        string        `json:"host"`
+	Action      string        `json:"action"`
+	Deployment  string        `json:"deployment,omitempty"`
+	Changes     []AuditChange `json:"changes,omitempty"`
+	Plan        string        `json:"plan,omitempty"`   // e.g. "1 to add, 0 to change, 0 to replace, 0 to destroy"
+	Result      string        `json:"result"`           // ok, error, cancelled or denied
+	Detail      string        `json:"detail,omitempty"` // message shown to the user
+}
+
+// AuditChange is a field changed by an action.
+type AuditChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Audit results.
+const (
+	auditOK        = "ok"
+	auditError     = "error"
+	auditCancelled = "cancelled"
+	auditDenied    = "denied"
+)
+
+// auditLogPath returns where the audit log is written; audit_log_path in the
+// config, or launcher-audit.jsonl next to apps_path.
+func auditLogPath(cfg Config) string {
+	if cfg.AuditLogPath != "" {
+		return cfg.AuditLogPath
+	}
+	return filepath.Join(filepath.Dir(filepath.Clean(cfg.AppsPath)), "launcher-audit.jsonl")
+}
+
+// auditedActions are the background terraform actions written to the audit log.
+var auditedActions = map[string]bool{"create": true, "apply": true, "apply-approved": true, "request": true, "destroy": true, "import": true, "rename": true}
+
+// auditResult classifies the outcome of an action.
+func auditResult(err error) string {
+	if err != nil {
+		return auditError
+	}
+	return auditOK
+}
+
+// planCounts summarises a plan in one line, or "" for an empty plan.
+func planCounts(p PlanSummary) string {
+	if len(p.Changes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d to add, %d to change, %d to replace, %d to destroy",
+		p.Count("create"), p.Count("update"), p.Count("replace"), p.Count("delete"))
+}
+
+// appendAudit stamps an entry with time, user and host and appends it to the audit log.
+func appendAudit(cfg Config, e AuditEntry) error {
+	e.Time = time.Now().UTC().Format(time.RFC3339)
+	e.User = osUser()
+	if u := currentUser(); u != e.User {
+		e.DisplayUser = u
+	}
+	e.Host, _ = os.Hostname()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	path := auditLogPath(cfg)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// recordAudit appends an entry and returns a note for the status line if
+// that failed; an unwritable audit log never blocks an action.
+func recordAudit(cfg Config, e AuditEntry) string {
+	if err := appendAudit(cfg, e); err != nil {
+		return " Audit log not written: " + err.Error()
+	}
+	return ""
+}
+
+// auditOutcome records an action on a deployment with its result or error.
+func auditOutcome(cfg Config, action, deployment, result string, err error, changes ...AuditChange) string {
+	e := AuditEntry{Action: action, Deployment: deployment, Changes: changes, Result: auditResult(err), Detail: strings.TrimSpace(result)}
+	if err != nil {
+		e.Detail = err.Error()
+	}
+	return recordAudit(cfg, e)
+}
+
+// auditTerraformDone records the end of an audited terraform action.
+func auditTerraformDone(cfg Config, msg terraformDoneMsg) string {
+	if !auditedActions[msg.action] {
+		return ""
+	}
+	e := AuditEntry{
+		Action:     msg.action,
+		Deployment: filepath.Base(msg.path),
+		Plan:       planCounts(msg.plan),
+		Result:     auditResult(msg.err),
+		Detail:     strings.TrimSpace(msg.result),
+	}
+	switch {
+	case msg.cancelled:
+		e.Result, e.Detail = auditCancelled, ""
+		if msg.unlockErr != nil {
+			e.Detail = msg.unlockErr.Error()
+		}
+	case msg.err != nil:
+		e.Detail = msg.err.Error()
+	}
+	return recordAudit(cfg, e)
+}
+
+// mapChanges lists the keys whose value differs between old and new.
+func mapChanges(keys []string, old, new map[string]string) []AuditChange {
+	var changes []AuditChange
+	for _, k := range keys {
+		if old[k] != new[k] {
+			changes = append(changes, AuditChange{Field: k, Old: old[k], New: new[k]})
+		}
+	}
+	return changes
+}
+
+// readAudit returns the audit log, oldest first. Lines that can't be parsed are skipped.
+func readAudit(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// formatChanges renders changed fields as "field: old → new; ...".
+func formatChanges(changes []AuditChange) string {
+	parts := make([]string, len(changes))
+	for i, c := range changes {
+		parts[i] = fmt.Sprintf("%s: %s → %s", c.Field, c.Old, c.New)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// writeAuditCSV writes entries as CSV with a header row.
+func writeAuditCSV(w io.Writer, entries []AuditEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "user", "display_user", "host", "action", "deployment", "changes", "plan", "result", "detail"}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := cw.Write([]string{e.Time, e.User, e.DisplayUser, e.Host, e.Action, e.Deployment, formatChanges(e.Changes), e.Plan, e.Result, e.Detail}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportAudit writes entries to a CSV file next to the audit log and returns its path.
+func exportAudit(cfg Config, entries []AuditEntry) (string, error) {
+	path := filepath.Join(filepath.Dir(auditLogPath(cfg)), fmt.Sprintf("launcher-audit_%s.csv", time.Now().UTC().Format(logTimeFormat)))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := writeAuditCSV(f, entries); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// filterAudit returns the entries at or after since (RFC 3339 or YYYY-MM-DD)
+// that match a filter query over user, display user, action and deployment.
+func filterAudit(entries []AuditEntry, since, query string) ([]AuditEntry, error) {
+	if since != "" && len(since) == len(expiryFormat) {
+		if _, err := time.Parse(expiryFormat, since); err != nil {
+			return nil, fmt.Errorf("invalid date %q", since)
+		}
+	} else if since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q", since)
+		}
+		since = t.UTC().Format(time.RFC3339) // entry times are UTC
+	}
+	var out []AuditEntry
+	for _, e := range entries {
+		if since != "" && e.Time < since {
+			continue
+		}
+		text := strings.ToLower(strings.Join([]string{e.User, e.DisplayUser, e.Action, e.Deployment, e.Result}, " "))
+		match := true
+		for _, term := range strings.Fields(strings.ToLower(query)) {
+			if !strings.Contains(text, term) {
+				match = false
+				break
+			}
+		}
+		if match {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// runAuditExport implements `launcher audit-export [--since date] [--out file] [filter...]`,
+// writing the matching audit entries as CSV to a file or stdout.
+func runAuditExport(cfg Config, args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("audit-export", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	since := fs.String("since", "", "only entries at or after this date (YYYY-MM-DD) or time (RFC 3339)")
+	out := fs.String("out", "", "CSV file to write, stdout by default")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	entries, err := readAudit(auditLogPath(cfg))
+	if err != nil {
+		fmt.Fprintln(stdout, "ERROR: could not read audit log:", err)
+		return 1
+	}
+	entries, err = filterAudit(entries, *since, strings.Join(fs.Args(), " "))
+	if err != nil {
+		fmt.Fprintln(stdout, "ERROR:", err)
+		return 2
+	}
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintln(stdout, "ERROR:", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeAuditCSV(w, entries); err != nil {
+		fmt.Fprintln(stdout, "ERROR: could not write CSV:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendAuditUser(t *testing.T) {
+	if osUser() == "" {
+		t.Skip("OS user unknown")
+	}
+	cfg := Config{AuditLogPath: filepath.Join(t.TempDir(), "audit.jsonl")}
+	t.Setenv("LAUNCHER_USER", "")
+	if err := appendAudit(cfg, AuditEntry{Action: "edit", Result: auditOK}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LAUNCHER_USER", "someone-else")
+	if err := appendAudit(cfg, AuditEntry{Action: "apply", Result: auditOK}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := readAudit(cfg.AuditLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	for _, e := range entries {
+		if e.User != osUser() {
+			t.Errorf("%s: user = %q, want the OS user %q", e.Action, e.User, osUser())
+		}
+	}
+	if entries[0].DisplayUser != "" || entries[1].DisplayUser != "someone-else" {
+		t.Errorf("display users = %q, %q; want \"\", someone-else", entries[0].DisplayUser, entries[1].DisplayUser)
+	}
+
+	found, err := filterAudit(entries, "", "someone-else")
+	if err != nil || len(found) != 1 || found[0].Action != "apply" {
+		t.Errorf("filterAudit() = %+v, %v; want the apply entry", found, err)
+	}
+	var b bytes.Buffer
+	if err := writeAuditCSV(&b, entries); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "time,user,display_user,") || !strings.Contains(b.String(), ",someone-else,") {
+		t.Errorf("CSV = %q", b.String())
+	}
+}
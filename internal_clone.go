@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// cloneFields are incremented past every value already used by an existing
+// deployment in the same scope, so a clone doesn't collide with its siblings.
+var cloneFields = []string{"platform_id", "vm_network_suffix", "vm_id_prefix"}
+
+// cloneScope returns the key under which values of field must be unique:
+// platform IDs per app and zone, network suffixes per zone and VMID prefixes
+// per cluster.
+func cloneScope(field string, values map[string]string) string {
+	switch field {
+	case "platform_id":
+		return values["vm_app"] + "/" + values["zone"]
+	case "vm_network_suffix":
+		return values["zone"]
+	case "vm_id_prefix":
+		return values["cluster"]
+	}
+	return ""
+}
+
+// nextFree increments a numeric value, keeping its zero padding, until
+// taken reports it free.
+func nextFree(v string, taken func(string) bool) (string, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return "", fmt.Errorf("'%s' is not a number", v)
+	}
+	for i := n + 1; i < n+1000; i++ {
+		next := fmt.Sprintf("%0*d", len(v), i)
+		if !taken(next) {
+			return next, nil
+		}
+	}
+	return "", fmt.Errorf("no free value after %s", v)
+}
+
+// cloneValues reads the form values of a deployment and increments the
+// cloneFields past the values used by the existing deployments. The platform
+// ID also skips values whose app directory already exists.
+func cloneValues(appsPath, provider string, src deploymentInfo, deployments []deploymentInfo) (map[string]string, error) {
+	raw, err := loadTfvars(filepath.Join(src.Path, "terraform.tfvars"))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		values[k] = tfvarsFormValue(v)
+	}
+	used := make(map[string][]string) // field|scope -> values
+	for _, dep := range deployments {
+		vals, err := loadTfvars(filepath.Join(dep.Path, "terraform.tfvars"))
+		if err != nil {
+			continue
+		}
+		other := make(map[string]string, len(vals))
+		for k, v := range vals {
+			other[k] = tfvarsFormValue(v)
+		}
+		for _, f := range cloneFields {
+			if v := other[f]; v != "" {
+				key := f + "|" + cloneScope(f, other)
+				used[key] = append(used[key], v)
+			}
+		}
+	}
+	for _, f := range cloneFields {
+		v, ok := values[f]
+		if !ok || v == "" {
+			continue
+		}
+		next, err := nextFree(v, func(c string) bool {
+			for _, u := range used[f+"|"+cloneScope(f, values)] {
+				if u == c {
+					return true
+				}
+			}
+			if f == "platform_id" {
+				dir := fmt.Sprintf("%s_%s_%s_%s", provider, values["vm_app"], values["zone"], c)
+				if _, err := os.Stat(filepath.Join(appsPath, dir)); err == nil {
+					return true
+				}
+			}
+			return false
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		values[f] = next
+	}
+	return values, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestNextFree(t *testing.T) {
+	taken := func(values ...string) func(string) bool {
+		return func(v string) bool {
+			return indexOf(v, values) >= 0
+		}
+	}
+	tests := []struct {
+		name    string
+		v       string
+		taken   func(string) bool
+		want    string
+		wantErr bool
+	}{
+		{"next value", "5", taken(), "6", false},
+		{"keeps zero padding", "01", taken(), "02", false},
+		{"skips taken values", "01", taken("02", "03"), "04", false},
+		{"grows past padding", "99", taken(), "100", false},
+		{"not a number", "ab", taken(), "", true},
+		{"nothing free", "1", func(string) bool { return true }, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextFree(tt.v, tt.taken)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("nextFree(%q) = %q, want %q", tt.v, got, tt.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,27 @@ type Config struct {
 	AWSRegion     string `yaml:"aws_region"`
 	TerraformPath string `yaml:"terraform_path"`
 	BackendType   string `yaml:"backend_type"` // optional, future use (s3|gitlab|github)
+
+	TerraformBinary  string              `yaml:"terraform_binary"`  // terraform (default) or tofu, or a full path
+	TerraformVersion string              `yaml:"terraform_version"` // optional constraint checked at startup, e.g. ">= 1.6, < 2.0"
+	TerraformArgs    map[string][]string `yaml:"terraform_args"`    // extra args per subcommand
+	VaultTFVarsPath  string              `yaml:"vault_tfvars_path"` // optional KV path injected as TF_VAR_*
+	LogPath          string              `yaml:"log_path"`          // optional; run logs default to <deployment>/.launcher/logs
+
+	Templates []TemplateSpec `yaml:"templates"` // optional catalog, defaults to template_path
+
+	ProtectedZones     []string `yaml:"protected_zones"`      // deployments in these zones can't be destroyed
+	TrashPath          string   `yaml:"trash_path"`           // defaults to <apps_path>/../.launcher-trash
+	TrashRetentionDays int      `yaml:"trash_retention_days"` // defaults to 7
+
+	MetricsRefreshSeconds int  `yaml:"metrics_refresh_seconds"` // VM metrics refresh in the details panel, defaults to 30
+	SnapshotBeforeApply   bool `yaml:"snapshot_before_apply"`   // snapshot all VMs of a deployment before each apply
+
+	PolicyPath    string   `yaml:"policy_path"`    // guardrails on deployment parameters, defaults to policy.yaml
+	ApprovalZones []string `yaml:"approval_zones"` // applies in these zones need a second user's approval
+	AuditLogPath  string   `yaml:"audit_log_path"` // append-only JSON Lines, defaults to launcher-audit.jsonl next to apps_path
+
+	Costs *CostTable `yaml:"costs"` // optional monthly prices for the usage dashboard
 }
 
 type Options struct {
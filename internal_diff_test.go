@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "changed line",
+			a:    "vm_count = 1\nvm_memory = 4096\n",
+			b:    "vm_count = 2\nvm_memory = 4096\n",
+			want: "--- v1\n+++ v2\n@@ -1,2 +1,2 @@\n-vm_count = 1\n+vm_count = 2\n vm_memory = 4096\n",
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "zone = \"dmz\"\n",
+			want: "--- v1\n+++ v2\n@@ -1,0 +1,1 @@\n+zone = \"dmz\"\n",
+		},
+		{
+			name: "distant changes make two hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "--- v1\n+++ v2\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("v1", "v2", tt.a, tt.b); got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
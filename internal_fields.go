@@ -7,12 +7,20 @@ import (
 )
 
 type FieldMeta struct {
-	Label    string `yaml:"label"`
-	Help     string `yaml:"help"`
-	ReadOnly bool   `yaml:"readOnly"`
-	Type     string `yaml:"type"`
+	Label      string `yaml:"label"`
+	Help       string `yaml:"help"`
+	ReadOnly   bool   `yaml:"readOnly"`
+	Type       string `yaml:"type"`
+	ChangeHint string `yaml:"changeHint"` // shown when the edit form changes the field
+	Impact     string `yaml:"impact"`     // impactReplace or impactInPlace; empty if unknown
 }
 
+// Impact classes of a field change.
+const (
+	impactReplace = "replace"  // terraform destroys and recreates the VMs
+	impactInPlace = "in-place" // terraform updates the VMs
+)
+
 // FieldsYaml is the structure for the fields.yaml file
 type FieldsYaml struct {
 	Fields map[string]FieldMeta `yaml:"fields"`
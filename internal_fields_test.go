@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+func TestEditChanges(t *testing.T) {
+	m := model{
+		editFormLabels: []string{"vm_memory", "zone", "vm_disk_size"},
+		editOriginal:   []string{"4096", "lan", "100G"},
+	}
+	for _, v := range []string{"8192", "lan", "100G, 200G"} {
+		ti := textinput.New()
+		ti.SetValue(v)
+		m.editFormInputs = append(m.editFormInputs, ti)
+	}
+	want := []editChange{{"vm_memory", "4096", "8192"}, {"vm_disk_size", "100G", "100G, 200G"}}
+	if got := editChanges(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("editChanges() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatEditValue(t *testing.T) {
+	tests := []struct {
+		key, v string
+		meta   FieldMeta
+		want   string
+	}{
+		{"vm_memory", "8192", FieldMeta{Type: "number"}, "8192"},
+		{"zone", "lan", FieldMeta{Type: "string"}, `"lan"`},
+		{"vm_disk_size", "100G, 200G", FieldMeta{Type: "string"}, `["100G", "200G"]`},
+	}
+	for _, tt := range tests {
+		if got := formatEditValue(tt.key, tt.v, tt.meta); got != tt.want {
+			t.Errorf("formatEditValue(%q, %q) = %s, want %s", tt.key, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestImpactWarnings(t *testing.T) {
+	fieldMeta := map[string]FieldMeta{
+		"vm_template": {Label: "Template", Impact: impactReplace},
+		"vm_memory":   {Label: "Memory", Impact: impactInPlace},
+	}
+	replace := &PlanSummary{Changes: []PlannedChange{{Address: "proxmox_vm_qemu.vm[0]", Actions: []string{"delete", "create"}}}}
+	update := &PlanSummary{Changes: []PlannedChange{{Address: "proxmox_vm_qemu.vm[0]", Actions: []string{"update"}}}}
+	template := []editChange{{Key: "vm_template", Old: "debian12", New: "debian13"}}
+	memory := []editChange{{Key: "vm_memory", Old: "4096", New: "8192"}}
+	tests := []struct {
+		name    string
+		changes []editChange
+		plan    *PlanSummary
+		want    []string
+	}{
+		{"replacing field, no plan", template, nil, []string{"⚠ Template forces replacement of the VMs (Ctrl+P to check the plan)."}},
+		{"in-place field, no plan", memory, nil, nil},
+		{"replacement confirmed", template, replace, []string{"⚠ Plan replaces 1 resource(s) because of Template."}},
+		{"unexpected replacement", memory, replace, []string{"⚠ Plan replaces 1 resource(s) although the changed fields are not marked as replacing."}},
+		{"expected replacement missing", template, update, []string{"Plan shows no replacement for Template."}},
+		{"in-place as expected", memory, update, nil},
+	}
+	for _, tt := range tests {
+		if got := impactWarnings(fieldMeta, tt.changes, tt.plan); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: impactWarnings() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
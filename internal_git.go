@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -31,3 +34,89 @@ func getGitStatus(repoPath string) (branch string, dirty bool, err error) {
 	}
 	return branch, false, nil
 }
+
+// dirtySuffix marks a template version rendered from a template dir with
+// uncommitted changes; the files differ from the recorded commit.
+const dirtySuffix = "-dirty"
+
+// templateVersion returns the last commit that touched dir, used to track
+// template versions, with dirtySuffix if dir has uncommitted changes.
+func templateVersion(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%H", "--", ".").Output()
+	if err != nil {
+		return "", err
+	}
+	version := strings.TrimSpace(string(out))
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain", "--", ".").Output()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(string(status)) != "" {
+		version += dirtySuffix
+	}
+	return version, nil
+}
+
+// exportTemplateVersion writes the files of dir as of rev into dst, keeping
+// their git file modes.
+func exportTemplateVersion(dir, rev, dst string) error {
+	rev = strings.TrimSuffix(rev, dirtySuffix)
+	out, err := exec.Command("git", "-C", dir, "ls-tree", "-r", rev, "--", ".").Output()
+	if err != nil {
+		return fmt.Errorf("git ls-tree %s failed: %v", rev, err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// <mode> <type> <object>\t<path>
+		info, name, ok := strings.Cut(line, "\t")
+		if !ok || name == "" {
+			continue
+		}
+		mode := strings.Fields(info)[0]
+		content, err := exec.Command("git", "-C", dir, "show", rev+":./"+name).Output()
+		if err != nil {
+			return fmt.Errorf("git show %s:%s failed: %v", rev, name, err)
+		}
+		target := filepath.Join(dst, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		switch mode {
+		case "120000":
+			err = os.Symlink(string(content), target)
+		case "100755":
+			err = os.WriteFile(target, content, 0755)
+		default:
+			err = os.WriteFile(target, content, 0644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeFile three-way merges base→theirs into ours with git merge-file and
+// returns the merged content and the number of conflicts.
+func mergeFile(ours, base, theirs []byte) ([]byte, int, error) {
+	tmp, err := os.MkdirTemp("", "launcher-merge-")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.RemoveAll(tmp)
+	paths := []string{filepath.Join(tmp, "deployment"), filepath.Join(tmp, "base"), filepath.Join(tmp, "template")}
+	for i, content := range [][]byte{ours, base, theirs} {
+		if err := os.WriteFile(paths[i], content, 0644); err != nil {
+			return nil, 0, err
+		}
+	}
+	cmd := exec.Command("git", "merge-file", "-p", "-L", "deployment", "-L", "base", "-L", "template", paths[0], paths[1], paths[2])
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+		// merge-file exits with the number of conflicts
+		return out, exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("git merge-file failed: %v", err)
+	}
+	return out, 0, nil
+}
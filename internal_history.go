@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HistoryEntry is one line of <deployment>/.launcher/history/index.jsonl.
+// Every change of terraform.tfvars is kept as a numbered copy; apply entries
+// point at the copy that was applied.
+type HistoryEntry struct {
+	Version int    `json:"version"`
+	Time    string `json:"time"`
+	User    string `json:"user"`
+	Action  string `json:"action"` // create, import, edit, revert, rollback, rename, request, approve, reject, apply
+	File    string `json:"file"`   // relative to the history dir
+	Applied bool   `json:"applied,omitempty"`
+}
+
+func historyDir(depDir string) string {
+	return filepath.Join(depDir, ".launcher", "history")
+}
+
+// currentUser names who runs the launcher for display and records;
+// LAUNCHER_USER overrides the OS user.
+func currentUser() string {
+	if u := os.Getenv("LAUNCHER_USER"); u != "" {
+		return u
+	}
+	if u := osUser(); u != "" {
+		return u
+	}
+	return os.Getenv("USER")
+}
+
+// osUser is the OS account running the launcher, or "" if it can't be read.
+// Approvals and policy user rules rely on it, since anyone can set
+// LAUNCHER_USER.
+func osUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// recordTfvars appends the current terraform.tfvars of a deployment to its
+// history. Unchanged content reuses the previous copy, so apply entries
+// don't duplicate files.
+func recordTfvars(depDir, action string, applied bool) (HistoryEntry, error) {
+	data, err := os.ReadFile(filepath.Join(depDir, "terraform.tfvars"))
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	entries, err := readHistory(depDir)
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	dir := historyDir(depDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return HistoryEntry{}, err
+	}
+	entry := HistoryEntry{
+		Version: len(entries) + 1,
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		User:    currentUser(),
+		Action:  action,
+		Applied: applied,
+	}
+	if n := len(entries); n > 0 {
+		if prev, err := os.ReadFile(filepath.Join(dir, entries[n-1].File)); err == nil && bytes.Equal(prev, data) {
+			entry.File = entries[n-1].File
+		}
+	}
+	if entry.File == "" {
+		entry.File = fmt.Sprintf("%04d.tfvars", entry.Version)
+		if err := os.WriteFile(filepath.Join(dir, entry.File), data, 0644); err != nil {
+			return entry, err
+		}
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return entry, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "index.jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return entry, err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return entry, err
+}
+
+// readHistory returns the history of a deployment, oldest first.
+func readHistory(depDir string) ([]HistoryEntry, error) {
+	f, err := os.Open(filepath.Join(historyDir(depDir), "index.jsonl"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// lastApplied returns the most recent apply entry, if any.
+func lastApplied(entries []HistoryEntry) (HistoryEntry, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Applied {
+			return entries[i], true
+		}
+	}
+	return HistoryEntry{}, false
+}
+
+func readHistoryVersion(depDir string, e HistoryEntry) (string, error) {
+	data, err := os.ReadFile(filepath.Join(historyDir(depDir), e.File))
+	return string(data), err
+}
+
+// historyZone returns the zone set in a version of terraform.tfvars.
+func historyZone(depDir string, e HistoryEntry) (string, error) {
+	data, err := readHistoryVersion(depDir, e)
+	if err != nil {
+		return "", err
+	}
+	vals, err := parseTfvars(strings.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return tfvarsFormValue(vals["zone"]), nil
+}
+
+// historyDiff diffs two versions; a nil entry stands for the current file.
+func historyDiff(depDir string, from, to *HistoryEntry) (string, error) {
+	read := func(e *HistoryEntry) (string, string, error) {
+		if e == nil {
+			data, err := os.ReadFile(filepath.Join(depDir, "terraform.tfvars"))
+			return "terraform.tfvars (current)", string(data), err
+		}
+		s, err := readHistoryVersion(depDir, *e)
+		return fmt.Sprintf("v%d (%s by %s)", e.Version, e.Action, e.User), s, err
+	}
+	aName, a, err := read(from)
+	if err != nil {
+		return "", err
+	}
+	bName, b, err := read(to)
+	if err != nil {
+		return "", err
+	}
+	if d := unifiedDiff(aName, bName, a, b); d != "" {
+		return d, nil
+	}
+	return "No differences.", nil
+}
+
+// revertTfvars restores a version of terraform.tfvars and records the revert.
+func revertTfvars(depDir string, e HistoryEntry) error {
+	data, err := readHistoryVersion(depDir, e)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(depDir, "terraform.tfvars"), []byte(data), 0644); err != nil {
+		return err
+	}
+	_, err = recordTfvars(depDir, "revert", false)
+	return err
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// importRequest describes existing VMs to adopt into a new deployment.
+type importRequest struct {
+	Template TemplateSpec
+	Preset   Preset
+	AppDir   string
+	Cluster  string
+	VMs      []ProxmoxVM
+	Values   map[string]string // form values; sizing is derived from the first VM
+}
+
+var (
+	diskKeyRe  = regexp.MustCompile(`^(scsi|virtio|sata|ide)\d+$`)
+	diskSizeRe = regexp.MustCompile(`size=([^,]+)`)
+)
+
+// vmSizing derives vm_cpu_cores, vm_memory and the disk fields from a Proxmox VM config.
+func vmSizing(vmCfg map[string]interface{}) map[string]string {
+	out := make(map[string]string)
+	if cores := attrString(vmCfg, "cores"); cores != "" {
+		out["vm_cpu_cores"] = cores
+	}
+	if mem := attrString(vmCfg, "memory"); mem != "" {
+		out["vm_memory"] = mem
+	}
+	var diskKeys []string
+	for k, v := range vmCfg {
+		s, _ := v.(string)
+		if diskKeyRe.MatchString(k) && !strings.Contains(s, "media=cdrom") && !strings.Contains(s, "cloudinit") {
+			diskKeys = append(diskKeys, k)
+		}
+	}
+	sort.Strings(diskKeys)
+	var sizes []string
+	for _, k := range diskKeys {
+		if m := diskSizeRe.FindStringSubmatch(vmCfg[k].(string)); m != nil {
+			sizes = append(sizes, m[1])
+		}
+	}
+	if len(sizes) > 0 {
+		out["vm_disk_count"] = fmt.Sprintf("%d", len(sizes))
+		out["vm_disk_size"] = strings.Join(sizes, ",")
+	}
+	return out
+}
+
+// prepareImport renders the deployment for an import request, with tfvars
+// sized after the first selected VM, and returns its path. The deployment is
+// removed again if it can't be completed.
+func prepareImport(cfg Config, req importRequest) (string, error) {
+	if len(req.VMs) == 0 {
+		return "", fmt.Errorf("no VMs selected")
+	}
+	destPath := filepath.Join(cfg.AppsPath, req.AppDir)
+	if _, err := os.Stat(destPath); err == nil {
+		return "", fmt.Errorf("deployment '%s' already exists", req.AppDir)
+	}
+	client, err := proxmoxClientForCluster(req.Cluster)
+	if err != nil {
+		return "", err
+	}
+	vmCfg, err := client.vmConfig(req.VMs[0].Node, req.VMs[0].VmID)
+	if err != nil {
+		return "", fmt.Errorf("could not read config of VM %d: %w", req.VMs[0].VmID, err)
+	}
+	values := make(map[string]string)
+	for _, key := range req.Template.Fields {
+		if v, ok := req.Preset.FormValue(key); ok {
+			values[key] = v
+		}
+	}
+	for k, v := range req.Values {
+		values[k] = v
+	}
+	for k, v := range vmSizing(vmCfg) {
+		values[k] = v
+	}
+	values["vm_count"] = fmt.Sprintf("%d", len(req.VMs))
+	values["cluster"] = req.Cluster
+	updates := formatTfvars(values)
+	data := TemplateData{
+		AppDir:  req.AppDir,
+		Preset:  req.Preset.Name,
+		Values:  values,
+		Vars:    updates,
+		Backend: newBackendConfig(cfg, req.AppDir),
+	}
+	if err := renderTemplateDir(req.Template.Path, destPath, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	if err := writeImportFiles(destPath, req, updates); err != nil {
+		_ = os.RemoveAll(destPath)
+		return "", err
+	}
+	return destPath, nil
+}
+
+// writeImportFiles writes the tfvars, history, meta and state of a rendered
+// import deployment.
+func writeImportFiles(destPath string, req importRequest, updates map[string]string) error {
+	if err := saveTfvars(filepath.Join(destPath, "terraform.tfvars"), updates); err != nil {
+		return fmt.Errorf("failed to write tfvars: %w", err)
+	}
+	if _, err := recordTfvars(destPath, "import", false); err != nil {
+		return err
+	}
+	version, _ := templateVersion(req.Template.Path)
+	meta := DeploymentMeta{
+		Template:        req.Template.Name,
+		TemplateSource:  req.Template.Path,
+		TemplateVersion: version,
+		Preset:          req.Preset.Name,
+		Owner:           currentUser(),
+	}
+	for _, vm := range req.VMs {
+		meta.ImportedVMIDs = append(meta.ImportedVMIDs, vm.VmID)
+	}
+	if err := writeDeploymentMeta(destPath, meta); err != nil {
+		return err
+	}
+	return setDeploymentState(destPath, "IMPORTING", "import")
+}
+
+// renderImportString renders an import address or ID for the i-th VM.
+func renderImportString(text string, i int, vm ProxmoxVM) (string, error) {
+	tmpl, err := template.New("import").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	err = tmpl.Execute(&b, map[string]interface{}{"Index": i, "VMID": vm.VmID, "Node": vm.Node, "Name": vm.Name})
+	return b.String(), err
+}
+
+// runImport initialises the deployment, imports every VM and reports the
+// residual plan. If a step fails, the deployment is abandoned so the import
+// can be retried.
+func runImport(ctx context.Context, tf TerraformRunner, destPath string, req importRequest) (string, error) {
+	result, imported, err := importVMs(ctx, tf, destPath, req)
+	if err != nil {
+		return "", abandonImport(ctx, tf, destPath, imported, err)
+	}
+	return result, nil
+}
+
+// importVMs does the work of runImport and returns the resource addresses
+// imported so far.
+func importVMs(ctx context.Context, tf TerraformRunner, destPath string, req importRequest) (string, []string, error) {
+	if err := runTerraformInit(ctx, tf, destPath); err != nil {
+		return "", nil, err
+	}
+	var imported []string
+	for i, vm := range req.VMs {
+		addr, err := renderImportString(req.Template.ImportAddress, i, vm)
+		if err != nil {
+			return "", imported, err
+		}
+		id, err := renderImportString(req.Template.ImportID, i, vm)
+		if err != nil {
+			return "", imported, err
+		}
+		if out, err := tf.Run(ctx, destPath, "import", "-input=false", addr, id); err != nil {
+			return "", imported, fmt.Errorf("terraform import %s %s failed: %v\n%s", addr, id, err, tailLines(string(out), 3))
+		}
+		imported = append(imported, addr)
+	}
+	planFile := "launcher-import.tfplan"
+	defer os.Remove(filepath.Join(destPath, planFile))
+	if out, err := tf.Run(ctx, destPath, "plan", "-input=false", "-out="+planFile); err != nil {
+		return "", imported, fmt.Errorf("terraform plan after import failed: %v\n%s", err, tailLines(string(out), 3))
+	}
+	plan, err := showPlan(ctx, tf, destPath, planFile)
+	if err != nil {
+		return "", imported, err
+	}
+	state := "DEPLOYED"
+	result := fmt.Sprintf("Imported %d VM(s) into '%s'; no residual changes.", len(req.VMs), req.AppDir)
+	if len(plan.Changes) > 0 {
+		state = "IMPORTED"
+		result = fmt.Sprintf("Imported %d VM(s) into '%s'; residual plan: %d to add, %d to change, %d to replace, %d to destroy. Review tfvars before applying.",
+			len(req.VMs), req.AppDir, plan.Count("create"), plan.Count("update"), plan.Count("replace"), plan.Count("delete"))
+	}
+	if err := setDeploymentState(destPath, state, "import"); err != nil {
+		return "", imported, err
+	}
+	return result, imported, nil
+}
+
+// abandonImport removes the imported VMs from the remote state again, which
+// leaves the VMs themselves alone, and then the deployment. If the state
+// can't be cleaned up the deployment is kept, since it is the only handle on
+// that state.
+func abandonImport(ctx context.Context, tf TerraformRunner, destPath string, imported []string, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+	if len(imported) > 0 {
+		args := append([]string{"state", "rm"}, imported...)
+		if out, err := tf.Run(ctx, destPath, args...); err != nil {
+			return fmt.Errorf("%w\nremoving the imported VMs from the state failed too, so '%s' was kept: %v\n%s", cause, filepath.Base(destPath), err, tailLines(string(out), 3))
+		}
+	}
+	if err := os.RemoveAll(destPath); err != nil {
+		return fmt.Errorf("%w\n'%s' could not be removed: %v", cause, filepath.Base(destPath), err)
+	}
+	return cause
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunImportFailure(t *testing.T) {
+	req := importRequest{
+		AppDir: "pve_web_lan_p1",
+		Template: TemplateSpec{
+			ImportAddress: "proxmox_vm_qemu.vm[{{ .Index }}]",
+			ImportID:      "{{ .Node }}/qemu/{{ .VMID }}",
+		},
+		VMs: []ProxmoxVM{{VmID: 101, Node: "pve1"}, {VmID: 102, Node: "pve1"}},
+	}
+	tests := []struct {
+		name     string
+		runner   *fakeRunner
+		wantRm   []string // state rm calls
+		wantKept bool
+	}{
+		{
+			name:   "init fails",
+			runner: &fakeRunner{err: map[string]error{"init": errors.New("exit status 1")}},
+		},
+		{
+			name:   "import fails",
+			runner: &fakeRunner{err: map[string]error{"import": errors.New("exit status 1")}},
+		},
+		{
+			name:   "plan fails after the imports",
+			runner: &fakeRunner{err: map[string]error{"plan": errors.New("exit status 1")}},
+			wantRm: []string{"state rm proxmox_vm_qemu.vm[0] proxmox_vm_qemu.vm[1]"},
+		},
+		{
+			name:     "state can't be cleaned up",
+			runner:   &fakeRunner{err: map[string]error{"plan": errors.New("exit status 1"), "state": errors.New("exit status 1")}},
+			wantRm:   []string{"state rm proxmox_vm_qemu.vm[0] proxmox_vm_qemu.vm[1]"},
+			wantKept: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), req.AppDir)
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := runImport(context.Background(), tt.runner, dir, req); err == nil {
+				t.Fatal("runImport() returned no error")
+			}
+			var rm []string
+			for _, c := range tt.runner.calls {
+				if strings.HasPrefix(c, "state ") {
+					rm = append(rm, c)
+				}
+			}
+			if !reflect.DeepEqual(rm, tt.wantRm) {
+				t.Errorf("state calls = %q, want %q", rm, tt.wantRm)
+			}
+			if _, err := os.Stat(dir); (err == nil) != tt.wantKept {
+				t.Errorf("deployment kept = %v, want %v", err == nil, tt.wantKept)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/x/ansi"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,22 +23,37 @@ type DeploymentState struct {
 }
 
 type deploymentInfo struct {
-	Name         string
-	Description  string
-	State        string
-	LastAction   string
-	LastModified string
-	Path         string
+	Name             string
+	Description      string
+	State            string
+	LastAction       string
+	LastModified     string
+	Path             string
+	Template         string
+	TemplateVersion  string
+	TemplateOutdated bool
+	Protected        bool
+	Owner            string
+	Team             string
+	Environment      string
+	Tags             []string
+	Ticket           string
+	Expires          string
+	Approval         string // status of the open change request, or ""
 }
 
 func loadTfvars(filename string) (map[string]string, error) {
-	m := make(map[string]string)
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
+	return parseTfvars(f)
+}
+
+func parseTfvars(r io.Reader) (map[string]string, error) {
+	m := make(map[string]string)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := scanner.Text()
 		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
@@ -69,46 +87,129 @@ func saveTfvars(filename string, updates map[string]string) error {
 	return os.WriteFile(filename, []byte(output), 0644)
 }
 
-func runTerraformInit(appDir string) error {
-	cmd := exec.Command("terraform", "init", "-input=false")
-	cmd.Dir = appDir
-	out, err := cmd.CombinedOutput()
+// stringFields are the create form fields written to tfvars as quoted strings.
+var stringFields = map[string]bool{
+	"platform_description": true,
+	"vm_app":               true,
+	"zone":                 true,
+	"cluster":              true,
+	"platform_id":          true,
+	"vm_template":          true,
+}
+
+// formatTfvars converts form values into HCL values for terraform.tfvars.
+func formatTfvars(values map[string]string) map[string]string {
+	updates := make(map[string]string, len(values))
+	for key, v := range values {
+		if key == "vm_disk_size" {
+			updates[key] = hclList(v)
+		} else if stringFields[key] {
+			updates[key] = fmt.Sprintf("\"%s\"", v)
+		} else {
+			updates[key] = v
+		}
+	}
+	return updates
+}
+
+func runTerraformInit(ctx context.Context, tf TerraformRunner, appDir string) error {
+	out, err := tf.Run(ctx, appDir, "init", "-input=false")
 	if err != nil {
-		return fmt.Errorf("terraform init failed: %v\n%s", err, string(out))
+		return fmt.Errorf("terraform init failed: %v\n%s", err, tailLines(ansi.Strip(string(out)), 3))
 	}
 	return nil
 }
 
-func runTerraformApply(appDir string) error {
-	cmd := exec.Command("terraform", "apply", "-auto-approve", "-input=false")
-	cmd.Dir = appDir
-	out, err := cmd.CombinedOutput()
+func runTerraformApply(ctx context.Context, tf TerraformRunner, appDir string) error {
+	out, err := tf.Run(ctx, appDir, "apply", "-auto-approve", "-input=false")
 	if err != nil {
-		return fmt.Errorf("terraform apply failed: %v\n%s", err, string(out))
+		return fmt.Errorf("terraform apply failed: %v\n%s", err, tailLines(ansi.Strip(string(out)), 3))
 	}
 	return nil
 }
 
-func runTerraformDestroy(appDir string) error {
-	cmd := exec.Command("terraform", "destroy", "-auto-approve", "-input=false")
-	cmd.Dir = appDir
-	out, err := cmd.CombinedOutput()
+func runTerraformDestroy(ctx context.Context, tf TerraformRunner, appDir string) error {
+	out, err := tf.Run(ctx, appDir, "destroy", "-auto-approve", "-input=false")
 	if err != nil {
-		return fmt.Errorf("terraform destroy failed: %v\n%s", err, string(out))
+		return fmt.Errorf("terraform destroy failed: %v\n%s", err, tailLines(ansi.Strip(string(out)), 3))
 	}
 	return nil
 }
 
-func runTerraformPlanDestroy(appDir string) error {
-	cmd := exec.Command("terraform", "plan", "-destroy", "-input=false")
-	cmd.Dir = appDir
-	out, err := cmd.CombinedOutput()
+// runTerraformPlanDestroy saves a destroy plan and returns its parsed resource changes.
+func runTerraformPlanDestroy(ctx context.Context, tf TerraformRunner, appDir string) (PlanSummary, error) {
+	planFile := "launcher-destroy.tfplan"
+	defer os.Remove(filepath.Join(appDir, planFile))
+	out, err := tf.Run(ctx, appDir, "plan", "-destroy", "-input=false", "-out="+planFile)
+	if err != nil {
+		return PlanSummary{}, fmt.Errorf("terraform plan -destroy failed: %v\n%s", err, tailLines(ansi.Strip(string(out)), 3))
+	}
+	return showPlan(ctx, tf, appDir, planFile)
+}
+
+// runTerraformPlanWithVars plans a deployment with an extra var file that
+// overrides terraform.tfvars, e.g. pending edit form changes.
+func runTerraformPlanWithVars(ctx context.Context, tf TerraformRunner, appDir string, vars map[string]string) (PlanSummary, error) {
+	varFile, err := os.CreateTemp("", "launcher-edit-*.tfvars")
+	if err != nil {
+		return PlanSummary{}, err
+	}
+	defer os.Remove(varFile.Name())
+	for _, k := range sortedKeys(vars) {
+		fmt.Fprintf(varFile, "%s = %s\n", k, vars[k])
+	}
+	if err := varFile.Close(); err != nil {
+		return PlanSummary{}, err
+	}
+	planFile := "launcher-edit.tfplan"
+	defer os.Remove(filepath.Join(appDir, planFile))
+	if err := runTerraformInit(ctx, tf, appDir); err != nil {
+		return PlanSummary{}, err
+	}
+	out, err := tf.Run(ctx, appDir, "plan", "-input=false", "-var-file="+varFile.Name(), "-out="+planFile)
+	if err != nil {
+		return PlanSummary{}, fmt.Errorf("terraform plan failed: %v\n%s", err, tailLines(ansi.Strip(string(out)), 3))
+	}
+	return showPlan(ctx, tf, appDir, planFile)
+}
+
+// showPlan parses a saved plan file with `terraform show -json`.
+func showPlan(ctx context.Context, tf TerraformRunner, appDir, planFile string) (PlanSummary, error) {
+	out, err := tf.Run(ctx, appDir, "show", "-json", planFile)
 	if err != nil {
-		return fmt.Errorf("terraform plan -destroy failed: %v\n%s", err, string(out))
+		return PlanSummary{}, fmt.Errorf("terraform show failed: %v", err)
+	}
+	return parsePlanJSON(out)
+}
+
+// runTerraformInitApply runs init and apply, recording each step in launcher.state.
+func runTerraformInitApply(ctx context.Context, tf TerraformRunner, appDir string) error {
+	if err := runTerraformInit(ctx, tf, appDir); err != nil {
+		return err
+	}
+	if err := setDeploymentState(appDir, "INITIALIZED", "init"); err != nil {
+		return fmt.Errorf("failed to update launcher.state (init): %w", err)
+	}
+	if err := runTerraformApply(ctx, tf, appDir); err != nil {
+		return err
+	}
+	if err := setDeploymentState(appDir, "DEPLOYED", "apply"); err != nil {
+		return fmt.Errorf("failed to update launcher.state (apply): %w", err)
 	}
 	return nil
 }
 
+func awsEnv(profile, region string) []string {
+	env := os.Environ()
+	if profile != "" {
+		env = append(env, fmt.Sprintf("AWS_PROFILE=%s", profile))
+	}
+	if region != "" {
+		env = append(env, fmt.Sprintf("AWS_REGION=%s", region))
+	}
+	return env
+}
+
 // deleteS3Object removes a single S3 object that stores the remote state.
 // It shells out to AWS CLI to avoid adding heavy SDK dependencies.
 // Assumes the bucket is shared; only the object at <appDir>/s3/terraform.tfstate is removed.
@@ -118,14 +219,7 @@ func deleteS3Object(bucket, key, profile, region string) error {
 	}
 	s3URI := fmt.Sprintf("s3://%s/%s", bucket, key)
 	cmd := exec.Command("aws", "s3", "rm", s3URI)
-	env := os.Environ()
-	if profile != "" {
-		env = append(env, fmt.Sprintf("AWS_PROFILE=%s", profile))
-	}
-	if region != "" {
-		env = append(env, fmt.Sprintf("AWS_REGION=%s", region))
-	}
-	cmd.Env = env
+	cmd.Env = awsEnv(profile, region)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("aws s3 rm failed: %v\n%s", err, string(out))
@@ -140,21 +234,93 @@ func deleteS3Prefix(bucket, prefix, profile, region string) error {
 	}
 	s3URI := fmt.Sprintf("s3://%s/%s", bucket, strings.TrimPrefix(prefix, "/"))
 	cmd := exec.Command("aws", "s3", "rm", s3URI, "--recursive")
-	env := os.Environ()
-	if profile != "" {
-		env = append(env, fmt.Sprintf("AWS_PROFILE=%s", profile))
+	cmd.Env = awsEnv(profile, region)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("aws s3 rm --recursive failed: %v\n%s", err, string(out))
 	}
-	if region != "" {
-		env = append(env, fmt.Sprintf("AWS_REGION=%s", region))
+	return nil
+}
+
+// copyS3Prefix downloads all objects under an S3 prefix into a local directory.
+func copyS3Prefix(bucket, prefix, dst, profile, region string) error {
+	if bucket == "" || prefix == "" {
+		return fmt.Errorf("missing bucket or prefix for S3 copy")
 	}
-	cmd.Env = env
+	s3URI := fmt.Sprintf("s3://%s/%s", bucket, strings.TrimPrefix(prefix, "/"))
+	cmd := exec.Command("aws", "s3", "cp", s3URI, dst, "--recursive")
+	cmd.Env = awsEnv(profile, region)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("aws s3 rm --recursive failed: %v\n%s", err, string(out))
+		return fmt.Errorf("aws s3 cp --recursive failed: %v\n%s", err, string(out))
 	}
 	return nil
 }
 
+// s3ObjectExists reports whether a single S3 object exists.
+func s3ObjectExists(bucket, key, profile, region string) (bool, error) {
+	cmd := exec.Command("aws", "s3api", "head-object", "--bucket", bucket, "--key", key)
+	cmd.Env = awsEnv(profile, region)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return true, nil
+	}
+	if strings.Contains(string(out), "Not Found") || strings.Contains(string(out), "404") {
+		return false, nil
+	}
+	return false, fmt.Errorf("aws s3api head-object failed: %v\n%s", err, string(out))
+}
+
+// listS3StatePrefixes returns the top-level prefixes of the bucket that hold a terraform state.
+func listS3StatePrefixes(bucket, profile, region string) ([]string, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("no s3_bucket configured")
+	}
+	cmd := exec.Command("aws", "s3api", "list-objects-v2", "--bucket", bucket, "--query", "Contents[].Key", "--output", "json")
+	cmd.Env = awsEnv(profile, region)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("aws s3api list-objects-v2 failed: %v", err)
+	}
+	var keys []string
+	// An empty bucket yields null
+	if err := json.Unmarshal(out, &keys); err != nil {
+		return nil, fmt.Errorf("could not parse bucket listing: %w", err)
+	}
+	seen := make(map[string]bool)
+	var prefixes []string
+	for _, key := range keys {
+		prefix, _, ok := strings.Cut(key, "/")
+		if ok && strings.HasSuffix(key, ".tfstate") && !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes, nil
+}
+
+// waitForStateUnlock polls until the S3 lock file of a deployment's state is gone.
+func waitForStateUnlock(cfg Config, appDir string, timeout time.Duration) error {
+	if cfg.S3Bucket == "" {
+		return nil
+	}
+	lockKey := newBackendConfig(cfg, filepath.Base(appDir)).Key + ".tflock"
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := s3ObjectExists(cfg.S3Bucket, lockKey, cfg.AWSProfile, cfg.AWSRegion)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("state is still locked (s3://%s/%s); run terraform force-unlock if no run is active", cfg.S3Bucket, lockKey)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
 func setDeploymentState(path string, state string, action string) error {
 	s := DeploymentState{
 		State:      state,
@@ -212,19 +378,49 @@ func listDeployments(appsDir string) ([]deploymentInfo, error) {
 					lastAction = st.Timestamp
 				}
 			}
+			meta, _ := readDeploymentMeta(full)
+			approval := ""
+			if req, err := readChangeRequest(full); err == nil && req != nil {
+				approval = req.Status
+			}
 			infos = append(infos, deploymentInfo{
-				Name:         e.Name(),
-				Description:  desc,
-				State:        state,
-				LastAction:   lastAction,
-				LastModified: stat.ModTime().Format("2006-01-02 15:04"),
-				Path:         full,
+				Name:            e.Name(),
+				Description:     desc,
+				State:           state,
+				LastAction:      lastAction,
+				LastModified:    stat.ModTime().Format("2006-01-02 15:04"),
+				Path:            full,
+				Template:        meta.Template,
+				TemplateVersion: meta.TemplateVersion,
+				Protected:       meta.Protected,
+				Owner:           meta.Owner,
+				Team:            meta.Team,
+				Environment:     meta.Environment,
+				Tags:            meta.Tags,
+				Ticket:          meta.Ticket,
+				Expires:         meta.Expires,
+				Approval:        approval,
 			})
 		}
 	}
 	return infos, nil
 }
 
+// markOutdatedTemplates flags deployments whose recorded template version
+// differs from the current version of their template.
+func markOutdatedTemplates(infos []deploymentInfo, templates []TemplateSpec) {
+	current := make(map[string]string)
+	for _, t := range templates {
+		if v, err := templateVersion(t.Path); err == nil {
+			current[t.Name] = v
+		}
+	}
+	for i := range infos {
+		v, ok := current[infos[i].Template]
+		infos[i].TemplateOutdated = ok && v != "" && infos[i].TemplateVersion != "" && infos[i].TemplateVersion != v
+	}
+}
+
 func copyDir(src string, dst string) error {
 	srcInfo, err := os.Stat(src)
 	if err != nil {
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const logTimeFormat = "20060102T150405Z"
+
+// runLog is one saved terraform run. Files are named <timestamp>_<action>.log,
+// or <timestamp>_<action>.<n>.log for further runs of the action within the
+// same second.
+type runLog struct {
+	Path   string
+	Time   time.Time
+	Seq    int
+	Action string
+	Result string
+}
+
+// maxRunLogSeq bounds the runs of one action per second that get a log.
+const maxRunLogSeq = 100
+
+// runLogDir returns where the logs of a deployment are kept: under the
+// deployment itself, or under <log_path>/<appDir> when a log dir is configured.
+func runLogDir(logPath, depDir string) string {
+	if logPath != "" {
+		return filepath.Join(logPath, filepath.Base(depDir))
+	}
+	return filepath.Join(depDir, ".launcher", "logs")
+}
+
+// createRunLog opens a new log file for a run and writes its header.
+func createRunLog(logDir, binary string, args []string) (*os.File, error) {
+	if err := os.MkdirAll(logDir, 0700); err != nil {
+		return nil, err
+	}
+	action := "run"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	now := time.Now().UTC()
+	var f *os.File
+	for seq := 1; ; seq++ {
+		name := fmt.Sprintf("%s_%s.log", now.Format(logTimeFormat), action)
+		if seq > 1 {
+			name = fmt.Sprintf("%s_%s.%d.log", now.Format(logTimeFormat), action, seq)
+		}
+		var err error
+		f, err = os.OpenFile(filepath.Join(logDir, name), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) || seq >= maxRunLogSeq {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(f, "# launcher: %s %s\n# started: %s\n\n", binary, strings.Join(args, " "), now.Format(time.RFC3339))
+	return f, nil
+}
+
+// finishRunLog writes the result footer and closes the log.
+func finishRunLog(f *os.File, runErr error) {
+	result := "ok"
+	if runErr != nil {
+		result = runErr.Error()
+	}
+	fmt.Fprintf(f, "\n# finished: %s\n# result: %s\n", time.Now().UTC().Format(time.RFC3339), result)
+	f.Close()
+}
+
+// listRunLogs returns the runs in logDir, newest first.
+func listRunLogs(logDir string) ([]runLog, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var logs []runLog
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		stamp, action, ok := strings.Cut(strings.TrimSuffix(name, ".log"), "_")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(logTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		seq := 1
+		if a, n, ok := strings.Cut(action, "."); ok {
+			if seq, err = strconv.Atoi(n); err != nil {
+				continue
+			}
+			action = a
+		}
+		path := filepath.Join(logDir, name)
+		logs = append(logs, runLog{Path: path, Time: t, Seq: seq, Action: action, Result: runLogResult(path)})
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if !logs[i].Time.Equal(logs[j].Time) {
+			return logs[i].Time.After(logs[j].Time)
+		}
+		return logs[i].Seq > logs[j].Seq
+	})
+	return logs, nil
+}
+
+// runLogResult reads the result footer of a log; runs without one are still running or were killed.
+func runLogResult(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return "?"
+	}
+	defer f.Close()
+	result := "incomplete"
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if r, ok := strings.CutPrefix(scanner.Text(), "# result: "); ok {
+			result = r
+		}
+	}
+	return result
+}
+
+// tailLines returns the last n non-empty lines of out, for short error messages.
+func tailLines(out string, n int) string {
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	var kept []string
+	for i := len(lines) - 1; i >= 0 && len(kept) < n; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			kept = append([]string{lines[i]}, kept...)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRunLogs(t *testing.T) {
+	dir := t.TempDir()
+	for _, runErr := range []error{nil, errors.New("exit status 1")} {
+		f, err := createRunLog(dir, "terraform", []string{"apply", "-input=false"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		finishRunLog(f, runErr)
+	}
+	// A run that never finished, e.g. the launcher was killed
+	f, err := createRunLog(dir, "terraform", []string{"apply"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	logs, err := listRunLogs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("got %d logs, want 3 (runs within a second get their own log)", len(logs))
+	}
+	want := []struct{ action, result string }{
+		{"apply", "incomplete"},
+		{"apply", "exit status 1"},
+		{"apply", "ok"},
+	}
+	for i, w := range want {
+		if logs[i].Action != w.action || logs[i].Result != w.result {
+			t.Errorf("logs[%d] = %s %q, want %s %q", i, logs[i].Action, logs[i].Result, w.action, w.result)
+		}
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	out := "first\n\nsecond\nthird\n\n"
+	if got := tailLines(out, 2); got != "second\nthird" {
+		t.Errorf("tailLines() = %q, want %q", got, "second\nthird")
+	}
+}
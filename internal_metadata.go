@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DeploymentMeta is stored next to launcher.state and describes how a deployment was created.
+type DeploymentMeta struct {
+	Template        string   `yaml:"template"`
+	TemplateSource  string   `yaml:"template_source"`
+	TemplateVersion string   `yaml:"template_version"` // git commit of the template dir
+	Preset          string   `yaml:"preset"`
+	CreatedAt       string   `yaml:"created_at"`
+	Protected       bool     `yaml:"protected"`
+	ImportedVMIDs   []int    `yaml:"imported_vmids,omitempty"`
+	ClonedFrom      string   `yaml:"cloned_from,omitempty"`    // app dir of the deployment this one was cloned from
+	PreviousNames   []string `yaml:"previous_names,omitempty"` // app dirs before renames, oldest first
+
+	// Ownership, edited with the metadata form
+	Owner       string   `yaml:"owner,omitempty"`
+	Team        string   `yaml:"team,omitempty"`
+	Environment string   `yaml:"environment,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+	Ticket      string   `yaml:"ticket,omitempty"`  // change ticket reference
+	Expires     string   `yaml:"expires,omitempty"` // expiryFormat
+}
+
+// expiryFormat is the date format of DeploymentMeta.Expires.
+const expiryFormat = "2006-01-02"
+
+// metaFormFields are the metadata form fields, in order, with their labels.
+var metaFormFields = []struct{ Key, Label, Help string }{
+	{"owner", "Owner", "Who is responsible for this deployment."},
+	{"team", "Team", "Owning team."},
+	{"environment", "Environment", "e.g. dev, test, prod."},
+	{"tags", "Tags", "Free-form tags, comma-separated."},
+	{"ticket", "Change Ticket", "Change ticket reference, e.g. CHG-1234."},
+	{"expires", "Expires", "Optional expiry date (YYYY-MM-DD)."},
+}
+
+// metaFormValues returns the metadata form values of meta, keyed like metaFormFields.
+func metaFormValues(meta DeploymentMeta) map[string]string {
+	return map[string]string{
+		"owner":       meta.Owner,
+		"team":        meta.Team,
+		"environment": meta.Environment,
+		"tags":        strings.Join(meta.Tags, ", "),
+		"ticket":      meta.Ticket,
+		"expires":     meta.Expires,
+	}
+}
+
+// applyMetaForm sets the ownership fields of meta from form values.
+func applyMetaForm(meta DeploymentMeta, values map[string]string) (DeploymentMeta, error) {
+	expires := strings.TrimSpace(values["expires"])
+	if expires != "" {
+		if _, err := time.Parse(expiryFormat, expires); err != nil {
+			return meta, fmt.Errorf("expiry '%s' is not a date (YYYY-MM-DD)", expires)
+		}
+	}
+	meta.Owner = strings.TrimSpace(values["owner"])
+	meta.Team = strings.TrimSpace(values["team"])
+	meta.Environment = strings.TrimSpace(values["environment"])
+	meta.Tags = parseTags(values["tags"])
+	meta.Ticket = strings.TrimSpace(values["ticket"])
+	meta.Expires = expires
+	return meta, nil
+}
+
+// parseTags splits comma-separated tags, dropping empty ones and duplicates.
+func parseTags(s string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// matchesFilter reports whether a deployment matches every term of a filter
+// query. Terms are case-insensitive substrings of the name, description,
+// owner, team, environment, tags or ticket; owner:, team:, env:, tag:,
+// ticket: and state: restrict a term to one attribute.
+func matchesFilter(dep deploymentInfo, query string) bool {
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		key, value, scoped := strings.Cut(term, ":")
+		var fields []string
+		switch {
+		case !scoped:
+			value = term
+			fields = append([]string{dep.Name, dep.Description, dep.Owner, dep.Team, dep.Environment, dep.Ticket}, dep.Tags...)
+		case key == "owner":
+			fields = []string{dep.Owner}
+		case key == "team":
+			fields = []string{dep.Team}
+		case key == "env" || key == "environment":
+			fields = []string{dep.Environment}
+		case key == "tag" || key == "tags":
+			fields = dep.Tags
+		case key == "ticket":
+			fields = []string{dep.Ticket}
+		case key == "state":
+			fields = []string{dep.State}
+		default:
+			value = term
+			fields = []string{dep.Name, dep.Description}
+		}
+		found := false
+		for _, f := range fields {
+			if strings.Contains(strings.ToLower(f), value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterDeployments returns the deployments matching query.
+func filterDeployments(infos []deploymentInfo, query string) []deploymentInfo {
+	if strings.TrimSpace(query) == "" {
+		return infos
+	}
+	var out []deploymentInfo
+	for _, dep := range infos {
+		if matchesFilter(dep, query) {
+			out = append(out, dep)
+		}
+	}
+	return out
+}
+
+func writeDeploymentMeta(path string, meta DeploymentMeta) error {
+	if meta.CreatedAt == "" {
+		meta.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(path, "launcher.meta"), data, 0644)
+}
+
+// readDeploymentMeta returns an empty DeploymentMeta for deployments created
+// before metadata was recorded.
+func readDeploymentMeta(path string) (DeploymentMeta, error) {
+	var meta DeploymentMeta
+	data, err := os.ReadFile(filepath.Join(path, "launcher.meta"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return meta, nil
+		}
+		return meta, err
+	}
+	err = yaml.Unmarshal(data, &meta)
+	return meta, err
+}
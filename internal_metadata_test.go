@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestMatchesFilter(t *testing.T) {
+	dep := deploymentInfo{
+		Name:        "pve_web_dmz_01",
+		Description: "Public web frontend",
+		State:       "DEPLOYED",
+		Owner:       "alice",
+		Team:        "platform",
+		Environment: "prod",
+		Tags:        []string{"web", "pci"},
+		Ticket:      "CHG-1234",
+	}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"web", true},
+		{"WEB dmz", true},
+		{"frontend", true},
+		{"chg-1234", true},
+		{"web missing", false},
+		{"owner:alice", true},
+		{"owner:bob", false},
+		{"team:plat", true},
+		{"env:prod", true},
+		{"environment:test", false},
+		{"tag:pci", true},
+		{"tags:gdpr", false},
+		{"ticket:chg", true},
+		{"state:deployed", true},
+		{"state:ready", false},
+		{"platform", true},        // unscoped terms search the team too
+		{"owner:platform", false}, // scoped terms don't
+		{"foo:web", false},        // unknown keys search name and description for the whole term
+	}
+	for _, tt := range tests {
+		if got := matchesFilter(dep, tt.query); got != tt.want {
+			t.Errorf("matchesFilter(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMetricsInterval is how often the details panel refreshes VM metrics.
+const defaultMetricsInterval = 30 * time.Second
+
+func metricsInterval(cfg Config) time.Duration {
+	if cfg.MetricsRefreshSeconds > 0 {
+		return time.Duration(cfg.MetricsRefreshSeconds) * time.Second
+	}
+	return defaultMetricsInterval
+}
+
+// rrdPoint is one sample of /nodes/{node}/qemu/{vmid}/rrddata. Samples taken
+// while the VM was off have no values and decode as zero.
+type rrdPoint struct {
+	Time      int64   `json:"time"`
+	CPU       float64 `json:"cpu"` // fraction of MaxCPU
+	MaxCPU    float64 `json:"maxcpu"`
+	Mem       float64 `json:"mem"`
+	MaxMem    float64 `json:"maxmem"`
+	DiskRead  float64 `json:"diskread"` // bytes/s
+	DiskWrite float64 `json:"diskwrite"`
+	NetIn     float64 `json:"netin"`
+	NetOut    float64 `json:"netout"`
+}
+
+// vmRRD returns the averaged samples of a VM for timeframe (hour, day, week...).
+func (c *proxmoxClient) vmRRD(node string, vmid int, timeframe string) ([]rrdPoint, error) {
+	var points []rrdPoint
+	err := c.get(fmt.Sprintf("/nodes/%s/qemu/%d/rrddata?timeframe=%s&cf=AVERAGE", node, vmid, timeframe), &points)
+	return points, err
+}
+
+// VMMetrics holds the last hour of a VM's load as series for sparklines.
+type VMMetrics struct {
+	VmID   int
+	Name   string
+	CPU    []float64 // cores in use
+	Mem    []float64 // MiB in use
+	DiskIO []float64 // bytes/s read+write
+	Net    []float64 // bytes/s in+out
+	Cores  float64   // vm_cpu_cores and vm_memory of the deployment
+	MemMB  float64
+	Hint   string // over/under-provisioning warning, or ""
+}
+
+// fetchVMMetrics reads the last hour of metrics of every VM of a deployment
+// and compares them against the deployment's vm_cpu_cores and vm_memory.
+func fetchVMMetrics(dep deploymentInfo) ([]VMMetrics, error) {
+	client, vms, err := deploymentVMs(dep)
+	if err != nil {
+		return nil, err
+	}
+	vals, _ := loadTfvars(filepath.Join(dep.Path, "terraform.tfvars"))
+	cores, _ := strconv.ParseFloat(strings.Trim(vals["vm_cpu_cores"], "\""), 64)
+	memMB, _ := strconv.ParseFloat(strings.Trim(vals["vm_memory"], "\""), 64)
+	out := make([]VMMetrics, 0, len(vms))
+	for _, vm := range vms {
+		points, err := client.vmRRD(vm.Node, vm.VmID, "hour")
+		if err != nil {
+			return nil, fmt.Errorf("metrics of VM %d: %w", vm.VmID, err)
+		}
+		m := VMMetrics{VmID: vm.VmID, Name: vm.Name, Cores: cores, MemMB: memMB}
+		for _, p := range points {
+			m.CPU = append(m.CPU, p.CPU*p.MaxCPU)
+			m.Mem = append(m.Mem, p.Mem/(1<<20))
+			m.DiskIO = append(m.DiskIO, p.DiskRead+p.DiskWrite)
+			m.Net = append(m.Net, p.NetIn+p.NetOut)
+		}
+		m.Hint = provisioningHint(m)
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// provisioningHint flags VMs whose average load over the hour is far from
+// what they were given: above 85% or below 10% of cores, above 90% or below
+// 25% of memory.
+func provisioningHint(m VMMetrics) string {
+	cores, memMB := m.Cores, m.MemMB
+	var hints []string
+	if cpu := average(m.CPU); cores > 0 && len(m.CPU) > 0 {
+		switch {
+		case cpu > 0.85*cores:
+			hints = append(hints, fmt.Sprintf("CPU busy (%.1f of %.0f cores)", cpu, cores))
+		case cpu < 0.10*cores && cores > 1:
+			hints = append(hints, fmt.Sprintf("CPU idle (%.1f of %.0f cores)", cpu, cores))
+		}
+	}
+	if mem := average(m.Mem); memMB > 0 && len(m.Mem) > 0 {
+		switch {
+		case mem > 0.90*memMB:
+			hints = append(hints, fmt.Sprintf("memory tight (%.0f of %.0f MB)", mem, memMB))
+		case mem < 0.25*memMB:
+			hints = append(hints, fmt.Sprintf("memory oversized (%.0f of %.0f MB)", mem, memMB))
+		}
+	}
+	return strings.Join(hints, ", ")
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders the last width values scaled to top (or to their own
+// maximum when top is 0), right-aligned in width columns.
+func sparkline(values []float64, width int, top float64) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	if top <= 0 {
+		for _, v := range values {
+			top = math.Max(top, v)
+		}
+	}
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", width-len(values)))
+	for _, v := range values {
+		i := 0
+		if top > 0 {
+			i = int(v / top * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[min(max(i, 0), len(sparkBlocks)-1)])
+	}
+	return b.String()
+}
+
+// formatRate renders bytes/s as B/s, K/s or M/s.
+func formatRate(v float64) string {
+	switch {
+	case v >= 1<<20:
+		return fmt.Sprintf("%.1fM/s", v/(1<<20))
+	case v >= 1<<10:
+		return fmt.Sprintf("%.0fK/s", v/(1<<10))
+	default:
+		return fmt.Sprintf("%.0fB/s", v)
+	}
+}
+
+func last(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return values[len(values)-1]
+}
+
+// metricsLines renders the sparklines of a VM for the details panel.
+func metricsLines(m VMMetrics) []string {
+	const w = 16
+	return []string{
+		fmt.Sprintf("  cpu %s %4.1f   mem %s %5.0fM", sparkline(m.CPU, w, m.Cores), last(m.CPU), sparkline(m.Mem, w, m.MemMB), last(m.Mem)),
+		fmt.Sprintf("  io  %s %-6s net %s %s", sparkline(m.DiskIO, w, 0), formatRate(last(m.DiskIO)), sparkline(m.Net, w, 0), formatRate(last(m.Net))),
+	}
+}
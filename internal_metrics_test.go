@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestProvisioningHint(t *testing.T) {
+	tests := []struct {
+		name string
+		m    VMMetrics
+		want string
+	}{
+		{"right-sized", VMMetrics{Cores: 4, MemMB: 4096, CPU: []float64{2, 2}, Mem: []float64{2048, 2048}}, ""},
+		{"busy and tight", VMMetrics{Cores: 2, MemMB: 1000, CPU: []float64{1.9, 1.8}, Mem: []float64{950, 950}}, "CPU busy (1.9 of 2 cores), memory tight (950 of 1000 MB)"},
+		{"idle and oversized", VMMetrics{Cores: 8, MemMB: 8192, CPU: []float64{0.2}, Mem: []float64{1024}}, "CPU idle (0.2 of 8 cores), memory oversized (1024 of 8192 MB)"},
+		{"one idle core is fine", VMMetrics{Cores: 1, CPU: []float64{0.01}}, ""},
+		{"no samples", VMMetrics{Cores: 4, MemMB: 4096}, ""},
+	}
+	for _, tt := range tests {
+		if got := provisioningHint(tt.m); got != tt.want {
+			t.Errorf("%s: provisioningHint() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		width  int
+		top    float64
+		want   string
+	}{
+		{[]float64{0, 1, 2}, 5, 2, "  ▁▄█"},
+		{[]float64{0, 5, 10}, 3, 0, "▁▄█"},  // scaled to their own maximum
+		{[]float64{1, 2, 3, 4}, 2, 4, "▆█"}, // only the last width values
+		{[]float64{8}, 1, 4, "█"},           // capped at top
+		{nil, 3, 0, "   "},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.values, tt.width, tt.top); got != tt.want {
+			t.Errorf("sparkline(%v, %d, %v) = %q, want %q", tt.values, tt.width, tt.top, got, tt.want)
+		}
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	for v, want := range map[float64]string{512: "512B/s", 2048: "2K/s", 3 << 20: "3.0M/s"} {
+		if got := formatRate(v); got != want {
+			t.Errorf("formatRate(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
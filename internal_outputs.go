@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/atotto/clipboard"
+	osc52 "github.com/aymanbagabas/go-osc52/v2"
+)
+
+// TerraformOutput is one entry of `terraform output -json`.
+type TerraformOutput struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// cachedOutputs is stored in launcher.outputs.json so the details panel
+// doesn't have to run terraform. Sensitive outputs are cached without their
+// value; fetchOutputValue reads it when it is revealed or copied.
+type cachedOutputs struct {
+	FetchedAt string                     `json:"fetched_at"`
+	Outputs   map[string]TerraformOutput `json:"outputs"`
+}
+
+// runTerraformOutput reads the outputs of a deployment and caches them.
+func runTerraformOutput(ctx context.Context, tf TerraformRunner, appDir string) (cachedOutputs, error) {
+	out, err := tf.Run(ctx, appDir, "output", "-json")
+	if err != nil {
+		return cachedOutputs{}, fmt.Errorf("terraform output failed: %v", err)
+	}
+	cache := cachedOutputs{FetchedAt: time.Now().UTC().Format(time.RFC3339)}
+	if err := json.Unmarshal(out, &cache.Outputs); err != nil {
+		return cachedOutputs{}, fmt.Errorf("could not parse terraform output: %w", err)
+	}
+	for name, o := range cache.Outputs {
+		if o.Sensitive {
+			o.Value = nil
+			cache.Outputs[name] = o
+		}
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return cache, err
+	}
+	return cache, os.WriteFile(filepath.Join(appDir, "launcher.outputs.json"), data, 0600)
+}
+
+// fetchOutputValue reads the current value of one output from terraform,
+// formatted like formatOutputValue.
+func fetchOutputValue(ctx context.Context, tf TerraformRunner, appDir, name string) (string, error) {
+	out, err := tf.Run(ctx, appDir, "output", "-json", name)
+	if err != nil {
+		return "", fmt.Errorf("terraform output %s failed: %v", name, err)
+	}
+	return formatOutputValue(bytes.TrimSpace(out)), nil
+}
+
+// loadCachedOutputs returns the outputs saved by the last runTerraformOutput.
+func loadCachedOutputs(appDir string) (cachedOutputs, error) {
+	var cache cachedOutputs
+	data, err := os.ReadFile(filepath.Join(appDir, "launcher.outputs.json"))
+	if err != nil {
+		return cache, err
+	}
+	err = json.Unmarshal(data, &cache)
+	return cache, err
+}
+
+// formatOutputValue renders strings as-is and everything else as compact JSON.
+func formatOutputValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+// copyToClipboard uses the system clipboard and falls back to OSC52 so it
+// also works over SSH.
+func copyToClipboard(s string) error {
+	if err := clipboard.WriteAll(s); err == nil {
+		return nil
+	}
+	_, err := osc52.New(s).WriteTo(os.Stderr)
+	return err
+}
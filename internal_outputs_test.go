@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSensitiveOutputsNotCached(t *testing.T) {
+	dir := t.TempDir()
+	tf := &fakeRunner{out: map[string]string{
+		"output": `{"ip": {"sensitive": false, "value": "10.0.0.5"}, "password": {"sensitive": true, "value": "hunter2"}}`,
+	}}
+	cache, err := runTerraformOutput(context.Background(), tf, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Outputs["password"].Value != nil || !cache.Outputs["password"].Sensitive {
+		t.Errorf("password = %+v, want sensitive with no value", cache.Outputs["password"])
+	}
+	if got := formatOutputValue(cache.Outputs["ip"].Value); got != "10.0.0.5" {
+		t.Errorf("ip = %q, want 10.0.0.5", got)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "launcher.outputs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("launcher.outputs.json contains the sensitive value: %s", data)
+	}
+
+	tf.out["output"] = `"hunter2"`
+	value, err := fetchOutputValue(context.Background(), tf, dir, "password")
+	if err != nil || value != "hunter2" {
+		t.Errorf("fetchOutputValue() = %q, %v, want hunter2", value, err)
+	}
+	if last := tf.calls[len(tf.calls)-1]; last != "output -json password" {
+		t.Errorf("last terraform call = %q, want output -json password", last)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PlannedChange is one resource change of a terraform plan.
+type PlannedChange struct {
+	Address string
+	Type    string
+	Actions []string // e.g. ["delete"], ["update"], ["delete", "create"]
+	Name    string   // VM name, when the resource has one
+	VMID    string
+}
+
+// PlanSummary is the parsed form of `terraform show -json <planfile>`.
+type PlanSummary struct {
+	Changes []PlannedChange
+}
+
+// Action classifies a change as create, update, delete or replace.
+func (c PlannedChange) Action() string {
+	switch {
+	case len(c.Actions) == 2:
+		return "replace"
+	case len(c.Actions) == 1:
+		return c.Actions[0]
+	default:
+		return strings.Join(c.Actions, ",")
+	}
+}
+
+// Count returns how many changes have the given Action.
+func (p PlanSummary) Count(action string) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action() == action {
+			n++
+		}
+	}
+	return n
+}
+
+func parsePlanJSON(data []byte) (PlanSummary, error) {
+	var plan struct {
+		ResourceChanges []struct {
+			Address string `json:"address"`
+			Type    string `json:"type"`
+			Change  struct {
+				Actions []string               `json:"actions"`
+				Before  map[string]interface{} `json:"before"`
+				After   map[string]interface{} `json:"after"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return PlanSummary{}, fmt.Errorf("could not parse plan JSON: %w", err)
+	}
+	var summary PlanSummary
+	for _, rc := range plan.ResourceChanges {
+		if len(rc.Change.Actions) == 1 && (rc.Change.Actions[0] == "no-op" || rc.Change.Actions[0] == "read") {
+			continue
+		}
+		attrs := rc.Change.Before
+		if attrs == nil {
+			attrs = rc.Change.After
+		}
+		summary.Changes = append(summary.Changes, PlannedChange{
+			Address: rc.Address,
+			Type:    rc.Type,
+			Actions: rc.Change.Actions,
+			Name:    attrString(attrs, "name"),
+			VMID:    attrString(attrs, "vmid", "vm_id"),
+		})
+	}
+	return summary, nil
+}
+
+// attrString returns the first of keys present in attrs, formatted as a string.
+func attrString(attrs map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := attrs[k]; ok && v != nil {
+			if f, ok := v.(float64); ok {
+				return fmt.Sprintf("%.0f", f)
+			}
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	return ""
+}
+
+// renderPlanSummary formats a plan for the scrollable plan panels.
+func renderPlanSummary(p PlanSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan: %d to add, %d to change, %d to replace, %d to destroy.\n\n",
+		p.Count("create"), p.Count("update"), p.Count("replace"), p.Count("delete"))
+	if len(p.Changes) == 0 {
+		b.WriteString("No changes.\n")
+	}
+	for _, c := range p.Changes {
+		line := fmt.Sprintf("  %-8s %-50s", c.Action(), c.Address)
+		if c.Name != "" {
+			line += "  name=" + c.Name
+		}
+		if c.VMID != "" {
+			line += "  vmid=" + c.VMID
+		}
+		if c.Action() == "replace" {
+			line += "  ⚠ forces replacement"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestParsePlanJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    []PlannedChange
+		wantErr bool
+	}{
+		{
+			name: "skips no-op and read",
+			json: `{"resource_changes": [
+				{"address": "a.noop", "type": "a", "change": {"actions": ["no-op"]}},
+				{"address": "data.a.read", "type": "a", "change": {"actions": ["read"]}}
+			]}`,
+		},
+		{
+			name: "create takes attributes from after",
+			json: `{"resource_changes": [
+				{"address": "proxmox_vm_qemu.vm[0]", "type": "proxmox_vm_qemu", "change": {"actions": ["create"], "before": null, "after": {"name": "web-01", "vmid": 5101}}}
+			]}`,
+			want: []PlannedChange{{Address: "proxmox_vm_qemu.vm[0]", Type: "proxmox_vm_qemu", Actions: []string{"create"}, Name: "web-01", VMID: "5101"}},
+		},
+		{
+			name: "replace and delete take attributes from before",
+			json: `{"resource_changes": [
+				{"address": "proxmox_vm_qemu.vm[1]", "type": "proxmox_vm_qemu", "change": {"actions": ["delete", "create"], "before": {"name": "web-02", "vm_id": 5102}, "after": {"name": "web-02b"}}},
+				{"address": "proxmox_vm_qemu.vm[2]", "type": "proxmox_vm_qemu", "change": {"actions": ["delete"], "before": {"name": "web-03", "vmid": 5103}, "after": null}}
+			]}`,
+			want: []PlannedChange{
+				{Address: "proxmox_vm_qemu.vm[1]", Type: "proxmox_vm_qemu", Actions: []string{"delete", "create"}, Name: "web-02", VMID: "5102"},
+				{Address: "proxmox_vm_qemu.vm[2]", Type: "proxmox_vm_qemu", Actions: []string{"delete"}, Name: "web-03", VMID: "5103"},
+			},
+		},
+		{
+			name:    "invalid JSON",
+			json:    `{"resource_changes": [`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePlanJSON([]byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if len(got.Changes) != len(tt.want) {
+				t.Fatalf("got %d changes, want %d: %+v", len(got.Changes), len(tt.want), got.Changes)
+			}
+			for i, c := range got.Changes {
+				w := tt.want[i]
+				if c.Address != w.Address || c.Type != w.Type || c.Name != w.Name || c.VMID != w.VMID || c.Action() != w.Action() {
+					t.Errorf("change %d = %+v, want %+v", i, c, w)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanSummaryCount(t *testing.T) {
+	p := PlanSummary{Changes: []PlannedChange{
+		{Actions: []string{"create"}},
+		{Actions: []string{"create"}},
+		{Actions: []string{"update"}},
+		{Actions: []string{"create", "delete"}},
+	}}
+	for action, want := range map[string]int{"create": 2, "update": 1, "replace": 1, "delete": 0} {
+		if got := p.Count(action); got != want {
+			t.Errorf("Count(%q) = %d, want %d", action, got, want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is a set of guardrails on deployment parameters, loaded from
+// policy.yaml. Rules are evaluated over the form values of a deployment, the
+// zone and cluster among them, its launcher template (as "template") and the
+// user running the launcher.
+type Policy struct {
+	Rules []PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule checks one field of deployments matching When. A rule with
+// Min/Max requires a number in range, one with Allowed a listed value; a
+// missing value violates either.
+type PolicyRule struct {
+	Name    string              `yaml:"name"`
+	When    map[string][]string `yaml:"when"` // field (or "user") -> values; all must match
+	Field   string              `yaml:"field"`
+	Min     *float64            `yaml:"min"`
+	Max     *float64            `yaml:"max"`
+	Allowed []string            `yaml:"allowed"`
+	Effect  string              `yaml:"effect"` // policyDeny or policyWarn
+	Message string              `yaml:"message"`
+}
+
+// Policy rule effects.
+const (
+	policyDeny = "deny"
+	policyWarn = "warn"
+)
+
+// PolicyViolation is a rule a deployment breaks.
+type PolicyViolation struct {
+	Rule    string
+	Effect  string
+	Message string
+}
+
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("%s [%s]: %s", strings.ToUpper(v.Effect), v.Rule, v.Message)
+}
+
+// loadPolicy reads the policy file; a missing file is an empty policy.
+func loadPolicy(path string) (Policy, error) {
+	var p Policy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return p, err
+	}
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return p, err
+	}
+	for i, r := range p.Rules {
+		if r.Field == "" {
+			return p, fmt.Errorf("rule %d (%s): field is required", i+1, r.Name)
+		}
+		if r.Effect != policyDeny && r.Effect != policyWarn {
+			return p, fmt.Errorf("rule %d (%s): effect must be %s or %s", i+1, r.Name, policyDeny, policyWarn)
+		}
+		if r.Min == nil && r.Max == nil && len(r.Allowed) == 0 {
+			return p, fmt.Errorf("rule %d (%s): needs min, max or allowed", i+1, r.Name)
+		}
+	}
+	return p, nil
+}
+
+func policyPath(cfg Config) string {
+	if cfg.PolicyPath != "" {
+		return cfg.PolicyPath
+	}
+	return "policy.yaml"
+}
+
+// Evaluate checks form values against the policy for user.
+func (p Policy) Evaluate(values map[string]string, user string) []PolicyViolation {
+	var out []PolicyViolation
+	for _, r := range p.Rules {
+		if !r.applies(values, user) {
+			continue
+		}
+		value := strings.TrimSpace(values[r.Field])
+		if problem := r.check(value); problem != "" {
+			msg := r.Message
+			if msg == "" {
+				msg = problem
+			}
+			out = append(out, PolicyViolation{Rule: r.Name, Effect: r.Effect, Message: msg})
+		}
+	}
+	return out
+}
+
+func (r PolicyRule) applies(values map[string]string, user string) bool {
+	for key, want := range r.When {
+		got := values[key]
+		if key == "user" {
+			got = user
+		}
+		if indexOf(got, want) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// check returns what is wrong with value, or "".
+func (r PolicyRule) check(value string) string {
+	if value == "" && (len(r.Allowed) > 0 || r.Min != nil || r.Max != nil) {
+		return fmt.Sprintf("%s is missing", r.Field)
+	}
+	if len(r.Allowed) > 0 && indexOf(value, r.Allowed) < 0 {
+		return fmt.Sprintf("%s '%s' is not allowed (allowed: %s)", r.Field, value, strings.Join(r.Allowed, ", "))
+	}
+	if r.Min == nil && r.Max == nil {
+		return ""
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Sprintf("%s '%s' is not a number", r.Field, value)
+	}
+	if r.Min != nil && n < *r.Min {
+		return fmt.Sprintf("%s %s is below the minimum of %g", r.Field, value, *r.Min)
+	}
+	if r.Max != nil && n > *r.Max {
+		return fmt.Sprintf("%s %s exceeds the maximum of %g", r.Field, value, *r.Max)
+	}
+	return ""
+}
+
+// policyDenials returns the violations with a deny effect.
+func policyDenials(violations []PolicyViolation) []PolicyViolation {
+	var out []PolicyViolation
+	for _, v := range violations {
+		if v.Effect == policyDeny {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func formatViolations(violations []PolicyViolation) string {
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = v.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// deploymentFormValues reads a deployment's tfvars as form values, plus its
+// launcher template from launcher.meta as "template".
+func deploymentFormValues(depPath string) (map[string]string, error) {
+	vars, err := loadTfvars(filepath.Join(depPath, "terraform.tfvars"))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(vars)+1)
+	for k, v := range vars {
+		values[k] = tfvarsFormValue(v)
+	}
+	if meta, err := readDeploymentMeta(depPath); err == nil && meta.Template != "" {
+		values["template"] = meta.Template
+	}
+	return values, nil
+}
+
+// runPolicyCheck implements `launcher policy-check [--user name] [deployment...]`:
+// it evaluates the policy against the named deployments, or all of them, and
+// exits non-zero if any rule denies.
+func runPolicyCheck(cfg Config, args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("policy-check", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	user := fs.String("user", osUser(), "user to evaluate user rules for")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	policy, err := loadPolicy(policyPath(cfg))
+	if err != nil {
+		fmt.Fprintln(stdout, "ERROR: could not load policy:", err)
+		return 1
+	}
+	infos, err := listDeployments(cfg.AppsPath)
+	if err != nil {
+		fmt.Fprintln(stdout, "ERROR: could not list deployments:", err)
+		return 1
+	}
+	if names := fs.Args(); len(names) > 0 {
+		var selected []deploymentInfo
+		for _, name := range names {
+			found := false
+			for _, dep := range infos {
+				if dep.Name == name {
+					selected, found = append(selected, dep), true
+				}
+			}
+			if !found {
+				fmt.Fprintf(stdout, "ERROR: no deployment named %s\n", name)
+				return 1
+			}
+		}
+		infos = selected
+	}
+	denied := 0
+	for _, dep := range infos {
+		values, err := deploymentFormValues(dep.Path)
+		if err != nil {
+			fmt.Fprintf(stdout, "%s: could not read tfvars: %v\n", dep.Name, err)
+			denied++
+			continue
+		}
+		violations := policy.Evaluate(values, *user)
+		if len(violations) == 0 {
+			fmt.Fprintf(stdout, "%s: ok\n", dep.Name)
+			continue
+		}
+		for _, v := range violations {
+			fmt.Fprintf(stdout, "%s: %s\n", dep.Name, v)
+		}
+		if len(policyDenials(violations)) > 0 {
+			denied++
+		}
+	}
+	if denied > 0 {
+		fmt.Fprintf(stdout, "%d deployment(s) violate the policy.\n", denied)
+		return 1
+	}
+	return 0
+}
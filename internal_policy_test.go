@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	num := func(f float64) *float64 { return &f }
+	policy := Policy{Rules: []PolicyRule{
+		{Name: "dmz-memory", When: map[string][]string{"zone": {"dmz"}}, Field: "vm_memory", Max: num(16384), Effect: policyDeny},
+		{Name: "cores", Field: "vm_cpu_cores", Min: num(2), Max: num(16), Effect: policyWarn},
+		{Name: "dmz-templates", When: map[string][]string{"zone": {"dmz"}}, Field: "template", Allowed: []string{"hardened"}, Effect: policyDeny, Message: "dmz needs the hardened template"},
+		{Name: "prod-owners", When: map[string][]string{"user": {"intern"}}, Field: "zone", Allowed: []string{"dev"}, Effect: policyDeny},
+		{Name: "no-limits", Field: "vm_count", Effect: policyWarn},
+	}}
+	tests := []struct {
+		name   string
+		values map[string]string
+		user   string
+		want   []string // violated rules
+	}{
+		{
+			name:   "within limits",
+			values: map[string]string{"zone": "dmz", "vm_memory": "8192", "vm_cpu_cores": "4", "template": "hardened"},
+			user:   "alice",
+		},
+		{
+			name:   "over the maximum",
+			values: map[string]string{"zone": "dmz", "vm_memory": "32768", "vm_cpu_cores": "4", "template": "hardened"},
+			user:   "alice",
+			want:   []string{"dmz-memory"},
+		},
+		{
+			name:   "when doesn't match",
+			values: map[string]string{"zone": "dev", "vm_memory": "32768", "vm_cpu_cores": "4"},
+			user:   "alice",
+		},
+		{
+			name:   "below the minimum and not allowed",
+			values: map[string]string{"zone": "dmz", "vm_memory": "8192", "vm_cpu_cores": "1", "template": "ubuntu"},
+			user:   "alice",
+			want:   []string{"cores", "dmz-templates"},
+		},
+		{
+			name:   "not a number",
+			values: map[string]string{"zone": "dev", "vm_cpu_cores": "four"},
+			user:   "alice",
+			want:   []string{"cores"},
+		},
+		{
+			name:   "missing values violate limits",
+			values: map[string]string{"zone": "dmz"},
+			user:   "alice",
+			want:   []string{"dmz-memory", "cores", "dmz-templates"},
+		},
+		{
+			name:   "user rule",
+			values: map[string]string{"zone": "staging", "vm_cpu_cores": "4"},
+			user:   "intern",
+			want:   []string{"prod-owners"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range policy.Evaluate(tt.values, tt.user) {
+				got = append(got, v.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("violated rules = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluateMessage(t *testing.T) {
+	policy := Policy{Rules: []PolicyRule{
+		{Name: "templates", Field: "template", Allowed: []string{"hardened"}, Effect: policyDeny, Message: "use the hardened template"},
+		{Name: "zones", Field: "zone", Allowed: []string{"dev"}, Effect: policyWarn},
+	}}
+	got := policy.Evaluate(map[string]string{"template": "ubuntu"}, "alice")
+	if len(got) != 2 {
+		t.Fatalf("got %d violations, want 2: %+v", len(got), got)
+	}
+	if got[0].Message != "use the hardened template" {
+		t.Errorf("message = %q, want the rule's message", got[0].Message)
+	}
+	if got[1].Message != "zone is missing" {
+		t.Errorf("message = %q, want %q", got[1].Message, "zone is missing")
+	}
+	if denials := policyDenials(got); len(denials) != 1 || denials[0].Rule != "templates" {
+		t.Errorf("policyDenials() = %+v, want only templates", denials)
+	}
+}
+
+func TestApplyPolicyDenial(t *testing.T) {
+	num := func(f float64) *float64 { return &f }
+	dir := t.TempDir()
+	m := model{
+		cfg:    Config{AppsPath: filepath.Join(dir, "apps"), AuditLogPath: filepath.Join(dir, "audit.jsonl")},
+		policy: Policy{Rules: []PolicyRule{{Name: "memory", Field: "vm_memory", Max: num(16384), Effect: policyDeny}}},
+	}
+	dep := filepath.Join(dir, "apps", "pve_web_lan_p1")
+	if err := os.MkdirAll(dep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(memory string) {
+		if err := os.WriteFile(filepath.Join(dep, "terraform.tfvars"), []byte("vm_memory = "+memory+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("8192")
+	if denial := applyPolicyDenial(m, dep, "apply"); denial != "" {
+		t.Errorf("applyPolicyDenial() = %q, want none", denial)
+	}
+	// e.g. a version reverted from history
+	write("32768")
+	if denial := applyPolicyDenial(m, dep, "apply"); !strings.Contains(denial, "memory") {
+		t.Errorf("applyPolicyDenial() = %q, want the memory rule", denial)
+	}
+	data, err := os.ReadFile(m.cfg.AuditLogPath)
+	if err != nil || !strings.Contains(string(data), auditDenied) {
+		t.Errorf("audit log = %q, %v; want a denied entry", data, err)
+	}
+}
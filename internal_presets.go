@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,8 +10,9 @@ import (
 )
 
 type Preset struct {
-	Name   string
-	Values map[string]interface{}
+	Name     string
+	Template string // optional template catalog entry, from the preset's "template" key
+	Values   map[string]interface{}
 }
 
 func loadPresets(presetsDir string) ([]Preset, error) {
@@ -27,7 +29,9 @@ func loadPresets(presetsDir string) ([]Preset, error) {
 				continue
 			}
 			name := strings.TrimSuffix(e.Name(), ".yaml")
-			out = append(out, Preset{Name: name, Values: values})
+			template, _ := values["template"].(string)
+			delete(values, "template")
+			out = append(out, Preset{Name: name, Template: template, Values: values})
 		}
 	}
 	return out, nil
@@ -42,3 +46,26 @@ func loadPreset(path string) (map[string]interface{}, error) {
 	err = yaml.Unmarshal(f, &out)
 	return out, err
 }
+
+// FormValue returns the preset's value for key in form representation
+// (lists become comma-separated).
+func (p Preset) FormValue(key string) (string, bool) {
+	val, ok := p.Values[key]
+	if !ok {
+		return "", false
+	}
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case int:
+		return fmt.Sprintf("%d", v), true
+	case []interface{}:
+		strs := []string{}
+		for _, e := range v {
+			strs = append(strs, fmt.Sprintf("%v", e))
+		}
+		return strings.Join(strs, ","), true
+	default:
+		return fmt.Sprintf("%v", v), true
+	}
+}
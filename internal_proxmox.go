@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	vault "github.com/hashicorp/vault/api"
@@ -20,9 +23,41 @@ type ProxmoxVM struct {
 	Name     string `json:"name"`
 	Node     string `json:"node"`
 	Template int    `json:"template"`
+	Status   string `json:"status"`
 }
 
-func getProxmoxCredsFromVault(cluster string) (apiUrl, tokenId, tokenSecret string, err error) {
+// vaultSession caches the logged-in Vault client until its token expires, so
+// terraform runs and Proxmox calls don't log in every time.
+var vaultSession struct {
+	sync.Mutex
+	client  *vault.Client
+	expires time.Time // zero for tokens without a TTL
+}
+
+// vaultTokenMargin is how long before its expiry a cached token is replaced.
+const vaultTokenMargin = 30 * time.Second
+
+// vaultLogin returns a Vault client authenticated with the AppRole from
+// TF_VAR_role_id/TF_VAR_secret_id, reusing the last login while its token is valid.
+func vaultLogin() (*vault.Client, error) {
+	vaultSession.Lock()
+	defer vaultSession.Unlock()
+	if c := vaultSession.client; c != nil && (vaultSession.expires.IsZero() || time.Now().Before(vaultSession.expires)) {
+		return c, nil
+	}
+	client, ttl, err := vaultAppRoleLogin()
+	if err != nil {
+		return nil, err
+	}
+	vaultSession.client, vaultSession.expires = client, time.Time{}
+	if ttl > 0 {
+		vaultSession.expires = time.Now().Add(ttl - min(vaultTokenMargin, ttl/2))
+	}
+	return client, nil
+}
+
+// vaultAppRoleLogin logs in to Vault and returns the client and its token TTL.
+func vaultAppRoleLogin() (*vault.Client, time.Duration, error) {
 	vaultAddr := os.Getenv("VAULT_ADDR")
 	if vaultAddr == "" {
 		vaultAddr = "http://127.0.0.1:8200"
@@ -30,33 +65,57 @@ func getProxmoxCredsFromVault(cluster string) (apiUrl, tokenId, tokenSecret stri
 	roleID := os.Getenv("TF_VAR_role_id")
 	secretID := os.Getenv("TF_VAR_secret_id")
 	if roleID == "" || secretID == "" {
-		return "", "", "", fmt.Errorf("vault approle credentials not set")
+		return nil, 0, fmt.Errorf("vault approle credentials not set")
 	}
 
 	cfg := vault.DefaultConfig()
 	cfg.Address = vaultAddr
 	client, err := vault.NewClient(cfg)
 	if err != nil {
-		return "", "", "", err
+		return nil, 0, err
 	}
 	secret, err := client.Logical().Write("auth/approle/login", map[string]interface{}{
 		"role_id":   roleID,
 		"secret_id": secretID,
 	})
 	if err != nil || secret == nil || secret.Auth == nil {
-		return "", "", "", fmt.Errorf("vault appRole login failed: %v", err)
+		return nil, 0, fmt.Errorf("vault appRole login failed: %v", err)
 	}
 	client.SetToken(secret.Auth.ClientToken)
+	return client, time.Duration(secret.Auth.LeaseDuration) * time.Second, nil
+}
 
-	secretPath := fmt.Sprintf("proxmox_api_keys/data/%s", cluster)
+// readVaultKV reads a KV secret, unwrapping the KV v2 "data" envelope.
+func readVaultKV(client *vault.Client, secretPath string) (map[string]interface{}, error) {
 	kv, err := client.Logical().Read(secretPath)
+	if err != nil {
+		// The token may have been revoked; log in again next time
+		vaultSession.Lock()
+		if vaultSession.client == client {
+			vaultSession.client = nil
+		}
+		vaultSession.Unlock()
+	}
 	if err != nil || kv == nil || kv.Data == nil {
-		return "", "", "", fmt.Errorf("vault read failed for %s: %v", secretPath, err)
+		return nil, fmt.Errorf("vault read failed for %s: %v", secretPath, err)
 	}
 	data := kv.Data
 	if v2, ok := data["data"].(map[string]interface{}); ok {
 		data = v2
 	}
+	return data, nil
+}
+
+func getProxmoxCredsFromVault(cluster string) (apiUrl, tokenId, tokenSecret string, err error) {
+	client, err := vaultLogin()
+	if err != nil {
+		return "", "", "", err
+	}
+	secretPath := fmt.Sprintf("proxmox_api_keys/data/%s", cluster)
+	data, err := readVaultKV(client, secretPath)
+	if err != nil {
+		return "", "", "", err
+	}
 	apiUrl, _ = data["proxmox_api_url"].(string)
 	tokenId, _ = data["proxmox_api_token_id"].(string)
 	tokenSecret, _ = data["proxmox_api_token_secret"].(string)
@@ -66,32 +125,145 @@ func getProxmoxCredsFromVault(cluster string) (apiUrl, tokenId, tokenSecret stri
 	return apiUrl, tokenId, tokenSecret, nil
 }
 
-func listProxmoxTemplates(apiUrl, tokenId, tokenSecret string) ([]ProxmoxVM, error) {
-	client := &http.Client{
-		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+// proxmoxClient talks to the Proxmox VE API of one cluster with an API token.
+type proxmoxClient struct {
+	apiURL      string
+	tokenID     string
+	tokenSecret string
+	http        *http.Client
+}
+
+func newProxmoxClient(apiUrl, tokenId, tokenSecret string) *proxmoxClient {
+	return &proxmoxClient{
+		apiURL:      apiUrl,
+		tokenID:     tokenId,
+		tokenSecret: tokenSecret,
+		http: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
 	}
-	url := fmt.Sprintf("https://%s:8006/api2/json/cluster/resources?type=vm", apiUrl)
+}
+
+// proxmoxClientForCluster builds a client from the cluster's credentials in Vault.
+func proxmoxClientForCluster(cluster string) (*proxmoxClient, error) {
+	apiURL, tokenID, tokenSecret, err := getProxmoxCredsFromVault(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Proxmox creds from Vault: %w", err)
+	}
+	return newProxmoxClient(apiURL, tokenID, tokenSecret), nil
+}
+
+// do calls an API path (relative to /api2/json) and decodes the "data" field into out.
+func (c *proxmoxClient) do(method, path string, form url.Values, out interface{}) error {
+	endpoint := fmt.Sprintf("https://%s:8006/api2/json%s", c.apiURL, path)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("PVEAPIToken=%s=%s", tokenId, tokenSecret))
-	resp, err := client.Do(req)
+	req.Header.Set("Authorization", fmt.Sprintf("PVEAPIToken=%s=%s", c.tokenID, c.tokenSecret))
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	var parsed struct {
-		Data []ProxmoxVM `json:"data"`
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("proxmox %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
 	}
-	if err := json.Unmarshal(body, &parsed); err != nil {
+	parsed := struct {
+		Data interface{} `json:"data"`
+	}{Data: out}
+	return json.Unmarshal(data, &parsed)
+}
+
+func (c *proxmoxClient) get(path string, out interface{}) error {
+	return c.do("GET", path, nil, out)
+}
+
+// listVMs returns all QEMU VMs and templates of the cluster.
+func (c *proxmoxClient) listVMs() ([]ProxmoxVM, error) {
+	var vms []ProxmoxVM
+	err := c.get("/cluster/resources?type=vm", &vms)
+	return vms, err
+}
+
+// vmConfig returns the raw configuration of a VM (cores, memory, scsi0, ...).
+func (c *proxmoxClient) vmConfig(node string, vmid int) (map[string]interface{}, error) {
+	var cfg map[string]interface{}
+	err := c.get(fmt.Sprintf("/nodes/%s/qemu/%d/config", node, vmid), &cfg)
+	return cfg, err
+}
+
+// VMStatus is the live state of a VM from /status/current.
+type VMStatus struct {
+	VmID   int     `json:"vmid"`
+	Name   string  `json:"name"`
+	Node   string  `json:"-"`
+	Status string  `json:"status"`
+	Uptime int64   `json:"uptime"` // seconds
+	CPU    float64 `json:"cpu"`    // fraction of CPUs in use
+	CPUs   int     `json:"cpus"`
+	Mem    int64   `json:"mem"`
+	MaxMem int64   `json:"maxmem"`
+}
+
+func (c *proxmoxClient) vmStatus(node string, vmid int) (VMStatus, error) {
+	var st VMStatus
+	err := c.get(fmt.Sprintf("/nodes/%s/qemu/%d/status/current", node, vmid), &st)
+	st.Node = node
+	return st, err
+}
+
+// vmPower requests start, stop, shutdown or reboot and returns the task UPID.
+func (c *proxmoxClient) vmPower(node string, vmid int, action string) (string, error) {
+	var upid string
+	err := c.do("POST", fmt.Sprintf("/nodes/%s/qemu/%d/status/%s", node, vmid, action), url.Values{}, &upid)
+	return upid, err
+}
+
+// waitTask polls a task until it stops and returns its exit status.
+func (c *proxmoxClient) waitTask(node, upid string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var task struct {
+			Status     string `json:"status"`
+			ExitStatus string `json:"exitstatus"`
+		}
+		if err := c.get(fmt.Sprintf("/nodes/%s/tasks/%s/status", node, url.PathEscape(upid)), &task); err != nil {
+			return err
+		}
+		if task.Status == "stopped" {
+			if task.ExitStatus != "OK" {
+				return fmt.Errorf("task %s: %s", upid, task.ExitStatus)
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("task %s still running after %s", upid, timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func listProxmoxTemplates(apiUrl, tokenId, tokenSecret string) ([]ProxmoxVM, error) {
+	vms, err := newProxmoxClient(apiUrl, tokenId, tokenSecret).listVMs()
+	if err != nil {
 		return nil, err
 	}
 	var templates []ProxmoxVM
-	for _, vm := range parsed.Data {
+	for _, vm := range vms {
 		if vm.Template == 1 {
 			templates = append(templates, vm)
 		}
@@ -99,6 +271,26 @@ func listProxmoxTemplates(apiUrl, tokenId, tokenSecret string) ([]ProxmoxVM, err
 	return templates, nil
 }
 
+// listProxmoxVMs returns the non-template VMs of a cluster.
+func listProxmoxVMs(cluster string) ([]ProxmoxVM, error) {
+	client, err := proxmoxClientForCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	vms, err := client.listVMs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Proxmox VMs: %w", err)
+	}
+	var out []ProxmoxVM
+	for _, vm := range vms {
+		if vm.Template != 1 {
+			out = append(out, vm)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].VmID < out[j].VmID })
+	return out, nil
+}
+
 func fetchTemplatesForCluster(cluster string) ([]string, error) {
 	apiURL, tokenID, tokenSecret, err := getProxmoxCredsFromVault(cluster)
 	if err != nil {
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+)
+
+// ttlOptions are the TTLs in days offered by the create form; 0 means none.
+var ttlOptions = []int{0, 1, 3, 7, 14, 30, 90}
+
+func formatTTL(days int) string {
+	if days == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%dd", days)
+}
+
+// expiryForTTL returns the expiry date of a deployment created now with a TTL.
+func expiryForTTL(days int, now time.Time) string {
+	if days == 0 {
+		return ""
+	}
+	return now.AddDate(0, 0, days).Format(expiryFormat)
+}
+
+// expiryTime returns when a deployment expires: at the end of its expiry date,
+// local time. ok is false without a valid expiry date.
+func expiryTime(expires string) (t time.Time, ok bool) {
+	d, err := time.ParseInLocation(expiryFormat, expires, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d.AddDate(0, 0, 1), true
+}
+
+func isExpired(dep deploymentInfo, now time.Time) bool {
+	t, ok := expiryTime(dep.Expires)
+	return ok && !now.Before(t)
+}
+
+// ttlBadge describes the time left until a deployment expires: "expired",
+// "3d left", "5h left", or "" without an expiry date.
+func ttlBadge(expires string, now time.Time) string {
+	t, ok := expiryTime(expires)
+	if !ok {
+		return ""
+	}
+	left := t.Sub(now)
+	switch {
+	case left <= 0:
+		return "⚠ expired"
+	case left < 24*time.Hour:
+		return fmt.Sprintf("%dh left", int(left.Hours())+1)
+	default:
+		return fmt.Sprintf("%dd left", int(left.Hours()/24))
+	}
+}
+
+// expiredDeployments returns the deployments whose expiry date has passed.
+func expiredDeployments(infos []deploymentInfo, now time.Time) []deploymentInfo {
+	var out []deploymentInfo
+	for _, dep := range infos {
+		if isExpired(dep, now) {
+			out = append(out, dep)
+		}
+	}
+	return out
+}
+
+// runReap implements `launcher reap`: it lists expired deployments and, with
+// --destroy --yes, destroys them the same way as the destroy confirmation,
+// including remote state backup and cleanup. Protected deployments are
+// listed but never destroyed.
+func runReap(cfg Config, args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("reap", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	destroy := fs.Bool("destroy", false, "destroy the expired deployments")
+	yes := fs.Bool("yes", false, "confirm --destroy")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	infos, err := listDeployments(cfg.AppsPath)
+	if err != nil {
+		fmt.Fprintln(stdout, "ERROR: could not list deployments:", err)
+		return 1
+	}
+	expired := expiredDeployments(infos, time.Now())
+	if len(expired) == 0 {
+		fmt.Fprintln(stdout, "No expired deployments.")
+		return 0
+	}
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tEXPIRES\tOWNER\tSTATE\tNOTE")
+	for _, dep := range expired {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", dep.Name, dep.Expires, dep.Owner, dep.State, protectionReason(cfg, dep))
+	}
+	w.Flush()
+	if !*destroy {
+		fmt.Fprintf(stdout, "%d expired deployment(s). Run `reap --destroy --yes` to destroy them.\n", len(expired))
+		return 0
+	}
+	if !*yes {
+		fmt.Fprintln(stdout, "Refusing to destroy without --yes.")
+		return 2
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	tf := newTerraformRunner(cfg)
+	failed := 0
+	for _, dep := range expired {
+		if ctx.Err() != nil {
+			fmt.Fprintln(stdout, "Interrupted; remaining deployments were not destroyed.")
+			return 1
+		}
+		fmt.Fprintf(stdout, "Destroying %s...\n", dep.Name)
+		result, err := destroyDeployment(ctx, tf, cfg, dep)
+		if note := auditOutcome(cfg, "reap", dep.Name, result, err); note != "" {
+			fmt.Fprintln(stdout, " "+note)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(stdout, "  %s: %v\n", dep.Name, err)
+			continue
+		}
+		fmt.Fprintln(stdout, "  "+result)
+	}
+	if failed > 0 {
+		fmt.Fprintf(stdout, "%d of %d expired deployment(s) could not be destroyed.\n", failed, len(expired))
+		return 1
+	}
+	return 0
+}
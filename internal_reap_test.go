@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLBadge(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.Local)
+	tests := []struct {
+		expires string
+		want    string
+	}{
+		{"", ""},
+		{"not-a-date", ""},
+		{"2026-03-09", "⚠ expired"},
+		{"2026-03-10", "12h left"}, // expires at the end of the day
+		{"2026-03-13", "3d left"},
+	}
+	for _, tt := range tests {
+		if got := ttlBadge(tt.expires, now); got != tt.want {
+			t.Errorf("ttlBadge(%q) = %q, want %q", tt.expires, got, tt.want)
+		}
+	}
+}
+
+func TestExpiredDeployments(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	infos := []deploymentInfo{
+		{Name: "old", Expires: "2026-03-09"},
+		{Name: "today", Expires: "2026-03-10"},
+		{Name: "forever"},
+	}
+	got := expiredDeployments(infos, now)
+	if len(got) != 1 || got[0].Name != "old" {
+		t.Errorf("expiredDeployments() = %v, want only old", got)
+	}
+	if want := now.AddDate(0, 0, 7).Format(expiryFormat); expiryForTTL(7, now) != want {
+		t.Errorf("expiryForTTL(7) = %q, want %q", expiryForTTL(7, now), want)
+	}
+	if expiryForTTL(0, now) != "" {
+		t.Error("a TTL of 0 must not set an expiry date")
+	}
+}
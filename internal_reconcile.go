@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of orphans found by reconcile.
+const (
+	orphanState     = "state"     // remote state prefix without a deployment directory
+	orphanDirectory = "directory" // deployed directory without remote state
+	orphanVM        = "vm"        // VM that belongs to no deployment
+	orphanNoVMs     = "no-vms"    // deployed directory whose VMs are gone
+	orphanShared    = "shared"    // VM claimed by more than one deployment
+)
+
+// Orphan is one inconsistency between apps/, the state bucket and Proxmox.
+type Orphan struct {
+	Kind    string
+	Name    string // deployment or state prefix; VM name for orphanVM
+	Cluster string
+	VM      ProxmoxVM
+	Detail  string
+}
+
+// reconcileReport lists orphans in every direction. Sources that could not be
+// read are listed in Errors and skipped, so they never produce false orphans.
+type reconcileReport struct {
+	Orphans []Orphan
+	Errors  []string
+}
+
+// vmIndexDigits is the number of digits templates append to vm_id_prefix to
+// number the VMs of a deployment: prefix 51 with vm_count 3 is VMIDs 5101-5103.
+const vmIndexDigits = 2
+
+// vmOwner reports whether a VM belongs to a deployment: by its exact VMID
+// range on the deployment's cluster or because it was imported into it.
+type vmOwner struct {
+	name     string
+	cluster  string
+	ids      map[int]bool // <vm_id_prefix>01 to <vm_id_prefix><vm_count>
+	imported map[int]bool
+}
+
+func newVMOwner(dep deploymentInfo) vmOwner {
+	vals, _ := loadTfvars(filepath.Join(dep.Path, "terraform.tfvars"))
+	meta, _ := readDeploymentMeta(dep.Path)
+	o := vmOwner{
+		name:     dep.Name,
+		cluster:  strings.Trim(vals["cluster"], "\""),
+		ids:      make(map[int]bool),
+		imported: make(map[int]bool),
+	}
+	for _, id := range deploymentVMIDs(strings.Trim(vals["vm_id_prefix"], "\""), strings.Trim(vals["vm_count"], "\"")) {
+		o.ids[id] = true
+	}
+	for _, id := range meta.ImportedVMIDs {
+		o.imported[id] = true
+	}
+	return o
+}
+
+// deploymentVMIDs returns the VMIDs of a deployment's VMs, or nil when the
+// prefix or count isn't a number.
+func deploymentVMIDs(prefix, count string) []int {
+	p, err := strconv.Atoi(prefix)
+	if err != nil || p <= 0 {
+		return nil
+	}
+	n := 1
+	if count != "" {
+		if n, err = strconv.Atoi(count); err != nil {
+			return nil
+		}
+	}
+	base := p
+	for i := 0; i < vmIndexDigits; i++ {
+		base *= 10
+	}
+	var ids []int
+	for i := 1; i <= n && i < base/p; i++ {
+		ids = append(ids, base+i)
+	}
+	return ids
+}
+
+func (o vmOwner) owns(cluster string, vmid int) bool {
+	if o.imported[vmid] {
+		return true
+	}
+	return o.cluster == cluster && o.ids[vmid]
+}
+
+// checkSoleOwner refuses VMs that another deployment in the same apps
+// directory also owns, so power and snapshot actions never reach a VM whose
+// owner is ambiguous.
+func checkSoleOwner(dep deploymentInfo, cluster string, vmids []int) error {
+	deployments, err := listDeployments(filepath.Dir(dep.Path))
+	if err != nil {
+		return fmt.Errorf("could not check VM ownership: %w", err)
+	}
+	var shared []string
+	for _, other := range deployments {
+		if other.Name == dep.Name {
+			continue
+		}
+		o := newVMOwner(other)
+		for _, id := range vmids {
+			if o.owns(cluster, id) {
+				shared = append(shared, fmt.Sprintf("%d (also '%s')", id, other.Name))
+			}
+		}
+	}
+	if len(shared) > 0 {
+		return fmt.Errorf("VMs claimed by more than one deployment: %s; fix vm_id_prefix or the imported VMIDs first", strings.Join(shared, ", "))
+	}
+	return nil
+}
+
+// reconcile cross-references the apps directory, the state bucket and the VMs of clusters.
+func reconcile(cfg Config, clusters []string) (reconcileReport, error) {
+	var report reconcileReport
+	deployments, err := listDeployments(cfg.AppsPath)
+	if err != nil {
+		return report, err
+	}
+	owners := make([]vmOwner, 0, len(deployments))
+	dirs := make(map[string]deploymentInfo)
+	for _, dep := range deployments {
+		dirs[dep.Name] = dep
+		owners = append(owners, newVMOwner(dep))
+	}
+
+	if prefixes, err := listS3StatePrefixes(cfg.S3Bucket, cfg.AWSProfile, cfg.AWSRegion); err != nil {
+		report.Errors = append(report.Errors, "state bucket: "+err.Error())
+	} else {
+		states := make(map[string]bool)
+		for _, p := range prefixes {
+			states[p] = true
+			if _, ok := dirs[p]; !ok {
+				report.Orphans = append(report.Orphans, Orphan{Kind: orphanState, Name: p,
+					Detail: fmt.Sprintf("s3://%s/%s/ has no directory in apps", cfg.S3Bucket, p)})
+			}
+		}
+		for _, dep := range deployments {
+			// New deployments, also those awaiting approval, have no state yet either
+			if !states[dep.Name] && dep.State != "READY" {
+				report.Orphans = append(report.Orphans, Orphan{Kind: orphanDirectory, Name: dep.Name,
+					Detail: fmt.Sprintf("state %s but no remote state", dep.State)})
+			}
+		}
+	}
+
+	for _, cluster := range clusters {
+		vms, err := listProxmoxVMs(cluster)
+		if err != nil {
+			report.Errors = append(report.Errors, cluster+": "+err.Error())
+			continue
+		}
+		found := make(map[string]bool)
+		for _, vm := range vms {
+			var claimed []string
+			for _, o := range owners {
+				if o.owns(cluster, vm.VmID) {
+					found[o.name] = true
+					claimed = append(claimed, o.name)
+				}
+			}
+			switch {
+			case len(claimed) == 0:
+				report.Orphans = append(report.Orphans, Orphan{Kind: orphanVM, Name: vm.Name, Cluster: cluster, VM: vm,
+					Detail: fmt.Sprintf("VMID %d on %s matches no deployment", vm.VmID, vm.Node)})
+			case len(claimed) > 1:
+				report.Orphans = append(report.Orphans, Orphan{Kind: orphanShared, Name: vm.Name, Cluster: cluster, VM: vm,
+					Detail: fmt.Sprintf("VMID %d on %s is claimed by %s", vm.VmID, vm.Node, strings.Join(claimed, ", "))})
+			}
+		}
+		for _, o := range owners {
+			if o.cluster == cluster && !found[o.name] && dirs[o.name].State == "DEPLOYED" {
+				report.Orphans = append(report.Orphans, Orphan{Kind: orphanNoVMs, Name: o.name, Cluster: cluster,
+					Detail: "deployed, but no VMs found; apply to recreate or destroy"})
+			}
+		}
+	}
+	sort.SliceStable(report.Orphans, func(i, j int) bool { return report.Orphans[i].Kind < report.Orphans[j].Kind })
+	return report, nil
+}
+
+// cleanupOrphan removes an orphan, keeping a trash entry so it can be recovered.
+func cleanupOrphan(cfg Config, o Orphan) (string, error) {
+	switch o.Kind {
+	case orphanState:
+		entry, err := newTrashEntry(cfg, deploymentInfo{Name: o.Name})
+		if err != nil {
+			return "", err
+		}
+		prefix := o.Name + "/"
+		if err := copyS3Prefix(cfg.S3Bucket, prefix, filepath.Join(entry, "remote-state"), cfg.AWSProfile, cfg.AWSRegion); err != nil {
+			return "", fmt.Errorf("remote state backup failed (state kept): %w", err)
+		}
+		if err := deleteS3Prefix(cfg.S3Bucket, prefix, cfg.AWSProfile, cfg.AWSRegion); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Remote state of '%s' moved to %s.", o.Name, entry), nil
+	case orphanDirectory:
+		path := filepath.Join(cfg.AppsPath, o.Name)
+		dep := deploymentInfo{Name: o.Name, Path: path}
+		if meta, err := readDeploymentMeta(path); err == nil {
+			dep.Protected = meta.Protected
+		}
+		if reason := protectionReason(cfg, dep); reason != "" {
+			return "", fmt.Errorf("refusing to clean up: %s", reason)
+		}
+		entry, err := newTrashEntry(cfg, dep)
+		if err != nil {
+			return "", err
+		}
+		if err := moveDir(path, filepath.Join(entry, "deployment")); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Directory of '%s' moved to %s.", o.Name, entry), nil
+	}
+	return "", fmt.Errorf("%s orphans can't be cleaned up from the launcher", o.Kind)
+}
+
+// adoptOrphan brings an orphaned state or directory back under management.
+// Orphaned VMs are adopted through the import scene instead.
+func adoptOrphan(cfg Config, o Orphan) (string, error) {
+	switch o.Kind {
+	case orphanState:
+		entry, err := latestTrashEntry(cfg, o.Name)
+		if err != nil {
+			return "", err
+		}
+		if err := moveDir(filepath.Join(entry, "deployment"), filepath.Join(cfg.AppsPath, o.Name)); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Directory of '%s' restored from %s.", o.Name, entry), nil
+	case orphanDirectory:
+		// Without remote state the next apply creates everything again
+		if err := setDeploymentState(filepath.Join(cfg.AppsPath, o.Name), "READY", "reconcile"); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("'%s' reset to READY; apply to recreate it.", o.Name), nil
+	}
+	return "", fmt.Errorf("%s orphans can't be adopted", o.Kind)
+}
+
+// latestTrashEntry returns the newest trash entry of name that still holds its directory.
+func latestTrashEntry(cfg Config, name string) (string, error) {
+	entries, err := os.ReadDir(trashDir(cfg))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	latest, latestAt := "", ""
+	for _, e := range entries {
+		dir := filepath.Join(trashDir(cfg), e.Name())
+		data, err := os.ReadFile(filepath.Join(dir, "trash.yaml"))
+		if err != nil {
+			continue
+		}
+		var entry TrashEntry
+		if yaml.Unmarshal(data, &entry) != nil || entry.Name != name || entry.DeletedAt <= latestAt {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, "deployment")); err == nil {
+			latest, latestAt = dir, entry.DeletedAt
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no trashed directory of '%s' to restore", name)
+	}
+	return latest, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeploymentVMIDs(t *testing.T) {
+	tests := []struct {
+		prefix, count string
+		want          []int
+	}{
+		{"51", "3", []int{5101, 5102, 5103}},
+		{"5", "", []int{501}},
+		{"5", "0", nil},
+		{"", "2", nil},
+		{"x", "2", nil},
+		{"51", "two", nil},
+	}
+	for _, tt := range tests {
+		if got := deploymentVMIDs(tt.prefix, tt.count); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("deploymentVMIDs(%q, %q) = %v, want %v", tt.prefix, tt.count, got, tt.want)
+		}
+	}
+	if got := deploymentVMIDs("7", "150"); len(got) != 99 || got[98] != 799 {
+		t.Errorf("deploymentVMIDs caps at 99 VMs, got %d ending %d", len(got), got[len(got)-1])
+	}
+}
+
+func TestVMOwnerOwns(t *testing.T) {
+	o := vmOwner{name: "a", cluster: "pve1", ids: map[int]bool{501: true}, imported: map[int]bool{9000: true}}
+	tests := []struct {
+		cluster string
+		vmid    int
+		want    bool
+	}{
+		{"pve1", 501, true},
+		{"pve2", 501, false},
+		{"pve1", 50, false},   // a prefix of the range isn't in it
+		{"pve1", 5010, false}, // neither is a longer VMID
+		{"pve2", 9000, true},  // imported VMs belong wherever they are
+	}
+	for _, tt := range tests {
+		if got := o.owns(tt.cluster, tt.vmid); got != tt.want {
+			t.Errorf("owns(%q, %d) = %v, want %v", tt.cluster, tt.vmid, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSoleOwner(t *testing.T) {
+	apps := t.TempDir()
+	newDep := func(name, tfvars string) deploymentInfo {
+		dir := filepath.Join(apps, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte(tfvars), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return deploymentInfo{Name: name, Path: dir}
+	}
+	a := newDep("proxmox_web_dev_01", "cluster = \"pve1\"\nvm_id_prefix = \"51\"\nvm_count = 2\n")
+	newDep("proxmox_db_dev_01", "cluster = \"pve1\"\nvm_id_prefix = \"51\"\nvm_count = 1\n")
+	newDep("proxmox_web_dev_02", "cluster = \"pve2\"\nvm_id_prefix = \"51\"\nvm_count = 2\n")
+
+	if err := checkSoleOwner(a, "pve1", []int{5102}); err != nil {
+		t.Errorf("5102 refused: %v", err)
+	}
+	err := checkSoleOwner(a, "pve1", []int{5101, 5102})
+	if err == nil || !strings.Contains(err.Error(), "5101 (also 'proxmox_db_dev_01')") {
+		t.Errorf("err = %v, want 5101 shared with proxmox_db_dev_01", err)
+	}
+}
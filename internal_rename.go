@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// renameRequest renames a deployment by changing the parts its app dir is
+// derived from: <provider>_<app>_<zone>_<platform_id>.
+type renameRequest struct {
+	Zone       string
+	PlatformID string
+}
+
+// renameTarget returns the new app dir of a deployment for req.
+func renameTarget(dep deploymentInfo, provider string, req renameRequest) (string, error) {
+	vals, err := loadTfvars(filepath.Join(dep.Path, "terraform.tfvars"))
+	if err != nil {
+		return "", err
+	}
+	app := tfvarsFormValue(vals["vm_app"])
+	if app == "" || req.Zone == "" || req.PlatformID == "" {
+		return "", fmt.Errorf("app, zone and platform ID are required")
+	}
+	return fmt.Sprintf("%s_%s_%s_%s", provider, app, req.Zone, req.PlatformID), nil
+}
+
+// renameViolations evaluates the policy against the deployment's values with
+// the new zone and platform ID.
+func renameViolations(policy Policy, dep deploymentInfo, req renameRequest) ([]PolicyViolation, error) {
+	values, err := deploymentFormValues(dep.Path)
+	if err != nil {
+		return nil, err
+	}
+	values["zone"], values["platform_id"] = req.Zone, req.PlatformID
+	return policy.Evaluate(values, osUser()), nil
+}
+
+// checkRenameApproval refuses zone changes into or out of an approval zone:
+// a rename applies nothing that could be reviewed, so the deployment has to
+// be recreated through an approved request instead.
+func checkRenameApproval(cfg Config, dep deploymentInfo, req renameRequest) error {
+	vals, err := loadTfvars(filepath.Join(dep.Path, "terraform.tfvars"))
+	if err != nil {
+		return err
+	}
+	zone := tfvarsFormValue(vals["zone"])
+	if req.Zone == zone {
+		return nil
+	}
+	if requiresApproval(cfg, dep.Path) || indexOf(req.Zone, cfg.ApprovalZones) >= 0 {
+		return fmt.Errorf("moving '%s' from %s to %s needs approval; renames can't be approved, create the deployment in the new zone instead", dep.Name, zone, req.Zone)
+	}
+	return nil
+}
+
+// rewriteBackendKey replaces the state key of oldDir with the one of newDir
+// in the deployment's top-level *.tf files, whether the backend came from the
+// default s3.tf or from a template. It returns the original contents of the
+// files it changed so the rename can be undone.
+func rewriteBackendKey(cfg Config, dir, oldDir, newDir string) (map[string][]byte, error) {
+	oldKey := newBackendConfig(cfg, oldDir).Key
+	newKey := newBackendConfig(cfg, newDir).Key
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string][]byte)
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return changed, err
+		}
+		if !bytes.Contains(content, []byte(oldKey)) {
+			continue
+		}
+		if err := os.WriteFile(f, bytes.ReplaceAll(content, []byte(oldKey), []byte(newKey)), 0644); err != nil {
+			return changed, err
+		}
+		changed[f] = content
+	}
+	if len(changed) == 0 {
+		return nil, fmt.Errorf("no backend with key %s found in %s", oldKey, filepath.Base(dir))
+	}
+	return changed, nil
+}
+
+// renameDeployment moves a deployment to its new app dir and migrates its
+// remote state to the new key: terraform is initialised against the old
+// backend, the directory is moved, the backend key is rewritten and
+// `init -migrate-state` copies the state. Run logs move along before
+// terraform runs in the new directory. The old state prefix is removed once
+// the state exists under the new key. Failures before the migration move
+// everything back.
+func renameDeployment(ctx context.Context, tf TerraformRunner, cfg Config, dep deploymentInfo, newDir string, req renameRequest) (string, error) {
+	oldDir := dep.Name
+	if newDir == oldDir {
+		return "", fmt.Errorf("'%s' already has that name", oldDir)
+	}
+	if err := checkRenameApproval(cfg, dep, req); err != nil {
+		return "", err
+	}
+	newPath := filepath.Join(filepath.Dir(dep.Path), newDir)
+	if _, err := os.Stat(newPath); err == nil {
+		return "", fmt.Errorf("refusing to rename: '%s' already exists", newDir)
+	}
+	if prefixes, err := listS3StatePrefixes(cfg.S3Bucket, cfg.AWSProfile, cfg.AWSRegion); err != nil {
+		return "", fmt.Errorf("could not check the state bucket: %w", err)
+	} else if indexOf(newDir, prefixes) >= 0 {
+		return "", fmt.Errorf("refusing to rename: s3://%s/%s/ already holds a state", cfg.S3Bucket, newDir)
+	}
+	if err := runTerraformInit(ctx, tf, dep.Path); err != nil {
+		return "", err
+	}
+	if err := moveDir(dep.Path, newPath); err != nil {
+		return "", fmt.Errorf("could not move the directory: %w", err)
+	}
+	// Move the run logs first, or the migration's own log would create the
+	// new log directory and leave the old one behind
+	oldLogs, newLogs := "", ""
+	if cfg.LogPath != "" {
+		oldLogs, newLogs = runLogDir(cfg.LogPath, dep.Path), runLogDir(cfg.LogPath, newPath)
+		if _, err := os.Stat(oldLogs); err != nil {
+			oldLogs = ""
+		} else if err := moveDir(oldLogs, newLogs); err != nil {
+			_ = moveDir(newPath, dep.Path)
+			return "", fmt.Errorf("could not move the run logs: %w", err)
+		}
+	}
+	undo := func(changed map[string][]byte, cause error) (string, error) {
+		for f, content := range changed {
+			_ = os.WriteFile(f, content, 0644)
+		}
+		if oldLogs != "" {
+			_ = moveDir(newLogs, oldLogs)
+		}
+		if err := moveDir(newPath, dep.Path); err != nil {
+			return "", fmt.Errorf("%v; moving the directory back also failed: %w", cause, err)
+		}
+		return "", cause
+	}
+	changed, err := rewriteBackendKey(cfg, newPath, oldDir, newDir)
+	if err != nil {
+		return undo(changed, err)
+	}
+	out, err := tf.Run(ctx, newPath, "init", "-input=false", "-migrate-state", "-force-copy")
+	if err != nil {
+		return undo(changed, fmt.Errorf("state migration failed: %v\n%s", err, tailLines(ansi.Strip(string(out)), 3)))
+	}
+
+	// The state lives under the new key from here on; only bookkeeping is left
+	var notes []string
+	updates := formatTfvars(map[string]string{"zone": req.Zone, "platform_id": req.PlatformID})
+	if err := saveTfvars(filepath.Join(newPath, "terraform.tfvars"), updates); err != nil {
+		notes = append(notes, "tfvars not updated: "+err.Error())
+	} else if _, err := recordTfvars(newPath, "rename", false); err != nil {
+		notes = append(notes, "history not recorded: "+err.Error())
+	}
+	meta, err := readDeploymentMeta(newPath)
+	if err == nil {
+		meta.PreviousNames = append(meta.PreviousNames, oldDir)
+		err = writeDeploymentMeta(newPath, meta)
+	}
+	if err != nil {
+		notes = append(notes, "launcher.meta not updated: "+err.Error())
+	}
+	if state, err := getDeploymentState(newPath); err == nil {
+		_ = setDeploymentState(newPath, state.State, "rename")
+	}
+	relinkClones(filepath.Dir(dep.Path), oldDir, newDir)
+	migrated, err := s3ObjectExists(cfg.S3Bucket, newBackendConfig(cfg, newDir).Key, cfg.AWSProfile, cfg.AWSRegion)
+	switch {
+	case err != nil:
+		notes = append(notes, "old state kept, new state could not be checked: "+err.Error())
+	case migrated:
+		if err := deleteS3Prefix(cfg.S3Bucket, oldDir+"/", cfg.AWSProfile, cfg.AWSRegion); err != nil {
+			notes = append(notes, "old state prefix not removed: "+err.Error())
+		}
+	}
+	result := fmt.Sprintf("Renamed '%s' to '%s'; state migrated.", oldDir, newDir)
+	if len(notes) > 0 {
+		result += " Warning: " + strings.Join(notes, "; ")
+	}
+	return result, nil
+}
+
+// relinkClones points the cloned_from of other deployments at the new name.
+func relinkClones(appsPath, oldDir, newDir string) {
+	deployments, err := listDeployments(appsPath)
+	if err != nil {
+		return
+	}
+	for _, dep := range deployments {
+		meta, err := readDeploymentMeta(dep.Path)
+		if err == nil && meta.ClonedFrom == oldDir {
+			meta.ClonedFrom = newDir
+			_ = writeDeploymentMeta(dep.Path, meta)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteBackendKey(t *testing.T) {
+	dir := t.TempDir()
+	s3tf := filepath.Join(dir, "s3.tf")
+	orig := "terraform {\n  backend \"s3\" {\n    key = \"pve_web_z1_01/s3/terraform.tfstate\"\n  }\n}\n"
+	if err := os.WriteFile(s3tf, []byte(orig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte("# no backend here\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := rewriteBackendKey(Config{}, dir, "pve_web_z1_01", "pve_web_z2_01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || string(changed[s3tf]) != orig {
+		t.Errorf("changed = %v, want only s3.tf with its original content", changed)
+	}
+	data, _ := os.ReadFile(s3tf)
+	if !strings.Contains(string(data), `key = "pve_web_z2_01/s3/terraform.tfstate"`) {
+		t.Errorf("s3.tf not rewritten:\n%s", data)
+	}
+
+	if _, err := rewriteBackendKey(Config{}, dir, "pve_web_z1_01", "pve_web_z3_01"); err == nil {
+		t.Error("expected an error when no file holds the old key")
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// TerraformRunner runs terraform subcommands in a deployment directory.
+// The launcher only talks to terraform through this interface so tests can
+// substitute a fake.
+type TerraformRunner interface {
+	Run(ctx context.Context, dir string, args ...string) ([]byte, error)
+}
+
+// unloggedSubcommands print state or outputs, sensitive values included, so
+// their output is kept out of the run logs.
+var unloggedSubcommands = map[string]bool{"output": true, "show": true, "state": true}
+
+// cancelGracePeriod is how long terraform may take to stop after SIGINT before it gets SIGTERM.
+const cancelGracePeriod = 10 * time.Second
+
+// execRunner runs a terraform-compatible binary (terraform or tofu).
+type execRunner struct {
+	binary    string
+	extraArgs map[string][]string // appended after the subcommand, e.g. apply: [-parallelism=4]
+	profile   string
+	region    string
+	vaultPath string // optional KV path whose keys are injected as TF_VAR_<key>
+	logPath   string // optional central log dir; logs live in the deployment otherwise
+}
+
+func newTerraformRunner(cfg Config) *execRunner {
+	binary := cfg.TerraformBinary
+	if binary == "" {
+		binary = "terraform"
+	}
+	return &execRunner{
+		binary:    binary,
+		extraArgs: cfg.TerraformArgs,
+		profile:   cfg.AWSProfile,
+		region:    cfg.AWSRegion,
+		vaultPath: cfg.VaultTFVarsPath,
+		logPath:   cfg.LogPath,
+	}
+}
+
+func (r *execRunner) Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	if len(args) > 0 {
+		if extra := r.extraArgs[args[0]]; len(extra) > 0 {
+			args = append(append([]string{args[0]}, extra...), args[1:]...)
+		}
+	}
+	env, err := r.environ()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, r.binary, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	var out bytes.Buffer
+	// A run log that can't be created never stops terraform
+	var w io.Writer = &out
+	logFile, logErr := createRunLog(runLogDir(r.logPath, dir), r.binary, args)
+	switch {
+	case logErr != nil:
+	case len(args) > 0 && unloggedSubcommands[args[0]]:
+		fmt.Fprintf(logFile, "# output not logged: %s may print sensitive values\n", args[0])
+	default:
+		w = io.MultiWriter(&out, logFile)
+	}
+	cmd.Stdout, cmd.Stderr = w, w
+	done := make(chan struct{})
+	defer close(done)
+	// On cancellation terraform gets SIGINT so it can stop cleanly and release
+	// the state lock, then SIGTERM after a grace period; exec kills it after WaitDelay.
+	cmd.Cancel = func() error {
+		go func() {
+			select {
+			case <-done:
+			case <-time.After(cancelGracePeriod):
+				_ = cmd.Process.Signal(syscall.SIGTERM)
+			}
+		}()
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = 2 * cancelGracePeriod
+	err = cmd.Run()
+	switch {
+	case logErr != nil:
+		if err != nil {
+			err = fmt.Errorf("%w (no run log: %v)", err, logErr)
+		}
+	default:
+		finishRunLog(logFile, err)
+		if err != nil {
+			err = fmt.Errorf("%w (log: %s)", err, logFile.Name())
+		}
+	}
+	return out.Bytes(), err
+}
+
+// environ builds the per-run environment: the ambient environment, the AWS
+// profile/region from config (unless already set) and TF_VAR_* from Vault.
+func (r *execRunner) environ() ([]string, error) {
+	profile, region := r.profile, r.region
+	if os.Getenv("AWS_PROFILE") != "" {
+		profile = ""
+	}
+	if os.Getenv("AWS_REGION") != "" {
+		region = ""
+	}
+	env := awsEnv(profile, region)
+	if r.vaultPath == "" {
+		return env, nil
+	}
+	vars, err := vaultTFVars(r.vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range sortedKeys(vars) {
+		env = append(env, fmt.Sprintf("TF_VAR_%s=%s", k, vars[k]))
+	}
+	return env, nil
+}
+
+// vaultTFVars reads a Vault KV secret whose keys are terraform variable names.
+func vaultTFVars(secretPath string) (map[string]string, error) {
+	client, err := vaultLogin()
+	if err != nil {
+		return nil, err
+	}
+	data, err := readVaultKV(client, secretPath)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string, len(data))
+	for k, v := range data {
+		vars[k] = fmt.Sprintf("%v", v)
+	}
+	return vars, nil
+}
+
+// checkTerraformVersion runs `<binary> version -json` and verifies it against constraint.
+func checkTerraformVersion(binary, constraint string) (string, error) {
+	if binary == "" {
+		binary = "terraform"
+	}
+	out, err := exec.Command(binary, "version", "-json").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s version failed: %v", binary, err)
+	}
+	// OpenTofu reports its version under the same key
+	var v struct {
+		Version string `json:"terraform_version"`
+	}
+	if err := json.Unmarshal(out, &v); err != nil {
+		return "", fmt.Errorf("could not parse %s version: %w", binary, err)
+	}
+	if constraint == "" {
+		return v.Version, nil
+	}
+	ok, err := versionSatisfies(v.Version, constraint)
+	if err != nil {
+		return v.Version, err
+	}
+	if !ok {
+		return v.Version, fmt.Errorf("%s %s does not satisfy %q", binary, v.Version, constraint)
+	}
+	return v.Version, nil
+}
+
+// versionSatisfies checks a version against comma-separated constraints
+// such as ">= 1.6, < 2.0" or "~> 1.9". Supported operators: = != > >= < <= ~>.
+func versionSatisfies(version, constraint string) (bool, error) {
+	have, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range strings.Split(constraint, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		op := "="
+		for _, candidate := range []string{"~>", ">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(c, candidate) {
+				op = candidate
+				c = strings.TrimSpace(strings.TrimPrefix(c, candidate))
+				break
+			}
+		}
+		want, err := parseVersion(c)
+		if err != nil {
+			return false, err
+		}
+		cmp := compareVersions(have, want)
+		var ok bool
+		switch op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case "~>":
+			// ~> 1.9 allows >= 1.9, < 2.0; ~> 1.9.3 allows >= 1.9.3, < 1.10.0
+			segments := strings.Count(c, ".") + 1
+			upper := append([]int(nil), want...)
+			bump := max(segments-2, 0)
+			upper[bump]++
+			for i := bump + 1; i < len(upper); i++ {
+				upper[i] = 0
+			}
+			ok = cmp >= 0 && compareVersions(have, upper) < 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parseVersion parses "1.9.3" (an optional leading v and any pre-release suffix are ignored).
+func parseVersion(s string) ([]int, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	out := make([]int, 3)
+	for i, p := range parts {
+		if i >= 3 {
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+func compareVersions(a, b []int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
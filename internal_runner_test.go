@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRunner answers terraform commands with canned output per subcommand
+// and records the commands it was given.
+type fakeRunner struct {
+	out   map[string]string
+	err   map[string]error
+	calls []string
+}
+
+func (f *fakeRunner) Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	return []byte(f.out[args[0]]), f.err[args[0]]
+}
+
+func TestVersionSatisfies(t *testing.T) {
+	tests := []struct {
+		version, constraint string
+		want                bool
+		wantErr             bool
+	}{
+		{"1.9.3", "", true, false},
+		{"1.9.3", "1.9.3", true, false},
+		{"v1.9.3", "= 1.9.3", true, false},
+		{"1.9.3", "!= 1.9.3", false, false},
+		{"1.9.3", ">= 1.6, < 2.0", true, false},
+		{"2.0.0", ">= 1.6, < 2.0", false, false},
+		{"1.5.7", "> 1.5.7", false, false},
+		{"1.5.7", "<= 1.5.7", true, false},
+		{"1.9.0", "~> 1.9", true, false},
+		{"1.12.1", "~> 1.9", true, false},
+		{"2.0.0", "~> 1.9", false, false},
+		{"1.9.5", "~> 1.9.3", true, false},
+		{"1.10.0", "~> 1.9.3", false, false},
+		{"1.8.0-beta1", ">= 1.8", true, false},
+		{"1.x", ">= 1.8", false, true},
+		{"1.9.3", ">= one", false, true},
+	}
+	for _, tt := range tests {
+		got, err := versionSatisfies(tt.version, tt.constraint)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("versionSatisfies(%q, %q) error = %v, want error %v", tt.version, tt.constraint, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("versionSatisfies(%q, %q) = %v, want %v", tt.version, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestShowPlan(t *testing.T) {
+	tf := &fakeRunner{out: map[string]string{"show": `{"resource_changes": [
+		{"address": "proxmox_vm_qemu.vm[0]", "type": "proxmox_vm_qemu", "change": {"actions": ["create"], "after": {"name": "web-01", "vmid": 5101}}}
+	]}`}}
+	plan, err := showPlan(context.Background(), tf, t.TempDir(), "edit.tfplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Count("create") != 1 || plan.Changes[0].VMID != "5101" {
+		t.Errorf("showPlan() = %+v, want one create of VMID 5101", plan)
+	}
+	if len(tf.calls) != 1 || tf.calls[0] != "show -json edit.tfplan" {
+		t.Errorf("terraform calls = %q, want [show -json edit.tfplan]", tf.calls)
+	}
+
+	tf = &fakeRunner{err: map[string]error{"show": errors.New("exit status 1")}}
+	if _, err := showPlan(context.Background(), tf, t.TempDir(), "edit.tfplan"); err == nil {
+		t.Error("showPlan() with a failing terraform show returned no error")
+	}
+}
+
+func TestExecRunnerCancel(t *testing.T) {
+	// A stand-in terraform that reports its arguments and exits on SIGINT
+	bin := filepath.Join(t.TempDir(), "terraform")
+	script := "#!/bin/sh\ntrap 'echo interrupted; exit 130' INT\necho \"args: $*\"\nwhile :; do sleep 0.1; done\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r := &execRunner{binary: bin, extraArgs: map[string][]string{"apply": {"-parallelism=4"}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	out, err := r.Run(ctx, t.TempDir(), "apply", "-input=false")
+	if err == nil {
+		t.Fatal("expected an error from the cancelled run")
+	}
+	if elapsed := time.Since(start); elapsed >= cancelGracePeriod {
+		t.Errorf("run took %v to stop, want it to stop on SIGINT", elapsed)
+	}
+	for _, want := range []string{"args: apply -parallelism=4 -input=false", "interrupted"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	}
+}
+
+func TestApplyAndReadOutputs(t *testing.T) {
+	tests := []struct {
+		name       string
+		tfvars     bool
+		runner     *fakeRunner
+		wantErr    bool
+		wantNote   string
+		wantOutput bool
+	}{
+		{
+			name:       "applied",
+			tfvars:     true,
+			runner:     &fakeRunner{out: map[string]string{"output": `{"ip": {"sensitive": false, "value": "10.0.0.5"}}`}},
+			wantOutput: true,
+		},
+		{
+			name:       "history fails, outputs still refreshed",
+			runner:     &fakeRunner{out: map[string]string{"output": `{"ip": {"sensitive": false, "value": "10.0.0.5"}}`}},
+			wantNote:   "History not recorded",
+			wantOutput: true,
+		},
+		{
+			name:     "outputs fail",
+			tfvars:   true,
+			runner:   &fakeRunner{err: map[string]error{"output": errors.New("exit status 1")}},
+			wantNote: "Outputs not refreshed",
+		},
+		{
+			name:    "apply fails",
+			tfvars:  true,
+			runner:  &fakeRunner{err: map[string]error{"apply": errors.New("exit status 1")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.tfvars {
+				if err := os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte("vm_count = 1\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			msg := applyAndReadOutputs(context.Background(), tt.runner, dir)
+			if (msg.err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", msg.err, tt.wantErr)
+			}
+			if tt.wantNote == "" && strings.TrimSpace(msg.result) != "" || !strings.Contains(msg.result, tt.wantNote) {
+				t.Errorf("result = %q, want note %q", msg.result, tt.wantNote)
+			}
+			_, err := loadCachedOutputs(dir)
+			if (err == nil) != tt.wantOutput {
+				t.Errorf("outputs cached = %v, want %v", err == nil, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SnapshotRecord is the launcher's record of a Proxmox snapshot taken of all
+// VMs of a deployment. The tfvars of the deployment at that time are kept
+// next to it as <name>.tfvars so a rollback restores both.
+type SnapshotRecord struct {
+	Name      string `yaml:"name"`
+	CreatedAt string `yaml:"created_at"`
+	Action    string `yaml:"action"`
+	VMIDs     []int  `yaml:"vmids"`
+}
+
+// errNoVMsToSnapshot is returned for deployments that have no VMs yet.
+var errNoVMsToSnapshot = errors.New("no VMs to snapshot")
+
+func snapshotDir(depDir string) string {
+	return filepath.Join(depDir, ".launcher", "snapshots")
+}
+
+// createSnapshot starts a snapshot of a VM (without RAM) and returns the task UPID.
+func (c *proxmoxClient) createSnapshot(node string, vmid int, name, description string) (string, error) {
+	var upid string
+	form := url.Values{"snapname": {name}, "description": {description}}
+	err := c.do("POST", fmt.Sprintf("/nodes/%s/qemu/%d/snapshot", node, vmid), form, &upid)
+	return upid, err
+}
+
+func (c *proxmoxClient) rollbackSnapshot(node string, vmid int, name string) (string, error) {
+	var upid string
+	err := c.do("POST", fmt.Sprintf("/nodes/%s/qemu/%d/snapshot/%s/rollback", node, vmid, name), url.Values{}, &upid)
+	return upid, err
+}
+
+func (c *proxmoxClient) deleteSnapshot(node string, vmid int, name string) (string, error) {
+	var upid string
+	err := c.do("DELETE", fmt.Sprintf("/nodes/%s/qemu/%d/snapshot/%s", node, vmid, name), nil, &upid)
+	return upid, err
+}
+
+// snapshotDeployment snapshots every VM of a deployment and records the
+// snapshot with a copy of the current tfvars. The snapshot is named after
+// the run, like its run log: <action>_<timestamp>. If it can't be completed
+// and recorded, the VM snapshots already taken are deleted again.
+func snapshotDeployment(dep deploymentInfo, action string) (SnapshotRecord, error) {
+	now := time.Now().UTC()
+	rec := SnapshotRecord{
+		Name:      fmt.Sprintf("%s_%s", action, now.Format(logTimeFormat)),
+		CreatedAt: now.Format(time.RFC3339),
+		Action:    action,
+	}
+	client, vms, err := exclusiveDeploymentVMs(dep)
+	if err != nil {
+		return rec, err
+	}
+	if len(vms) == 0 {
+		return rec, fmt.Errorf("'%s': %w", dep.Name, errNoVMsToSnapshot)
+	}
+	tfvars, err := os.ReadFile(filepath.Join(dep.Path, "terraform.tfvars"))
+	if err != nil {
+		return rec, err
+	}
+	rec, err = takeSnapshots(client, vms, rec)
+	if err != nil {
+		return rec, err
+	}
+	if err := writeSnapshotRecord(dep.Path, rec, tfvars); err != nil {
+		return rec, discardSnapshots(client, vms, rec, err)
+	}
+	return rec, nil
+}
+
+// takeSnapshots snapshots the VMs one by one and adds them to rec.VMIDs. If
+// one fails, the snapshots taken so far are deleted again.
+func takeSnapshots(client *proxmoxClient, vms []ProxmoxVM, rec SnapshotRecord) (SnapshotRecord, error) {
+	for _, vm := range vms {
+		upid, err := client.createSnapshot(vm.Node, vm.VmID, rec.Name, "launcher: before "+rec.Action)
+		if err != nil {
+			return rec, discardSnapshots(client, vms, rec, fmt.Errorf("snapshot of VM %d failed: %w", vm.VmID, err))
+		}
+		if err := client.waitTask(vm.Node, upid, 5*time.Minute); err != nil {
+			return rec, discardSnapshots(client, vms, rec, fmt.Errorf("snapshot of VM %d: %w", vm.VmID, err))
+		}
+		rec.VMIDs = append(rec.VMIDs, vm.VmID)
+	}
+	return rec, nil
+}
+
+// discardSnapshots deletes the snapshots of rec.VMIDs after cause stopped the
+// snapshot, so no snapshot is left without a record. VMs whose snapshot
+// couldn't be deleted are added to the error.
+func discardSnapshots(client *proxmoxClient, vms []ProxmoxVM, rec SnapshotRecord, cause error) error {
+	var left []int
+	for _, vm := range vms {
+		if indexOfInt(vm.VmID, rec.VMIDs) < 0 {
+			continue
+		}
+		upid, err := client.deleteSnapshot(vm.Node, vm.VmID, rec.Name)
+		if err == nil {
+			err = client.waitTask(vm.Node, upid, 5*time.Minute)
+		}
+		if err != nil {
+			left = append(left, vm.VmID)
+		}
+	}
+	if len(left) > 0 {
+		return fmt.Errorf("%w; snapshot %s is left on VM(s) %v and has to be deleted in Proxmox", cause, rec.Name, left)
+	}
+	return cause
+}
+
+// writeSnapshotRecord saves the record and tfvars of a snapshot.
+func writeSnapshotRecord(depDir string, rec SnapshotRecord, tfvars []byte) error {
+	dir := snapshotDir(depDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, rec.Name+".tfvars"), tfvars, 0644); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(rec)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, rec.Name+".yaml"), data, 0644)
+	}
+	if err != nil {
+		_ = os.Remove(filepath.Join(dir, rec.Name+".tfvars"))
+	}
+	return err
+}
+
+// listSnapshots returns the recorded snapshots of a deployment, newest first.
+func listSnapshots(depDir string) ([]SnapshotRecord, error) {
+	files, err := filepath.Glob(filepath.Join(snapshotDir(depDir), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var recs []SnapshotRecord
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var rec SnapshotRecord
+		if yaml.Unmarshal(data, &rec) == nil && rec.Name != "" {
+			recs = append(recs, rec)
+		}
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].CreatedAt > recs[j].CreatedAt })
+	return recs, nil
+}
+
+// snapshotVMs runs op on every VM of the record that still exists in the deployment.
+func snapshotVMs(dep deploymentInfo, rec SnapshotRecord, op func(c *proxmoxClient, vm ProxmoxVM) (string, error)) error {
+	client, vms, err := exclusiveDeploymentVMs(dep)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]ProxmoxVM, len(vms))
+	for _, vm := range vms {
+		byID[vm.VmID] = vm
+	}
+	for _, id := range rec.VMIDs {
+		vm, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("VM %d of snapshot %s no longer exists", id, rec.Name)
+		}
+		upid, err := op(client, vm)
+		if err != nil {
+			return fmt.Errorf("VM %d: %w", id, err)
+		}
+		if err := client.waitTask(vm.Node, upid, 5*time.Minute); err != nil {
+			return fmt.Errorf("VM %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// rollbackDeployment restores the VMs of a deployment to a snapshot and puts
+// back the tfvars saved with it. The terraform state isn't touched, so the
+// deployment is marked ROLLED_BACK until the next plan/apply.
+func rollbackDeployment(dep deploymentInfo, rec SnapshotRecord) (string, error) {
+	err := snapshotVMs(dep, rec, func(c *proxmoxClient, vm ProxmoxVM) (string, error) {
+		return c.rollbackSnapshot(vm.Node, vm.VmID, rec.Name)
+	})
+	if err != nil {
+		return "", fmt.Errorf("rollback to %s failed: %w", rec.Name, err)
+	}
+	tfvars, err := os.ReadFile(filepath.Join(snapshotDir(dep.Path), rec.Name+".tfvars"))
+	if err != nil {
+		return "", fmt.Errorf("VMs rolled back, but the saved tfvars are missing: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dep.Path, "terraform.tfvars"), tfvars, 0644); err != nil {
+		return "", fmt.Errorf("VMs rolled back, but tfvars could not be restored: %w", err)
+	}
+	if _, err := recordTfvars(dep.Path, "rollback", false); err != nil {
+		return "", err
+	}
+	if err := setDeploymentState(dep.Path, "ROLLED_BACK", "rollback"); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("'%s' rolled back to %s; run a plan before the next apply.", dep.Name, rec.Name), nil
+}
+
+// deleteDeploymentSnapshot removes a snapshot from the VMs and its record.
+func deleteDeploymentSnapshot(dep deploymentInfo, rec SnapshotRecord) (string, error) {
+	err := snapshotVMs(dep, rec, func(c *proxmoxClient, vm ProxmoxVM) (string, error) {
+		return c.deleteSnapshot(vm.Node, vm.VmID, rec.Name)
+	})
+	if err != nil {
+		return "", fmt.Errorf("deleting %s failed: %w", rec.Name, err)
+	}
+	base := filepath.Join(snapshotDir(dep.Path), rec.Name)
+	os.Remove(base + ".tfvars")
+	if err := os.Remove(base + ".yaml"); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Snapshot %s deleted.", rec.Name), nil
+}
+
+// snapshotBeforeApply takes a snapshot before an apply when
+// snapshot_before_apply is set and the deployment already has VMs. It returns
+// a note for the result; a failed snapshot must abort the apply.
+func snapshotBeforeApply(cfg Config, dir string) (string, error) {
+	if !cfg.SnapshotBeforeApply {
+		return "", nil
+	}
+	dep := deploymentInfo{Name: filepath.Base(dir), Path: dir}
+	rec, err := snapshotDeployment(dep, "apply")
+	switch {
+	case errors.Is(err, errNoVMsToSnapshot):
+		return " (no VMs to snapshot yet)", nil
+	case err != nil:
+		return "", fmt.Errorf("apply aborted, snapshot failed: %w", err)
+	}
+	return " Snapshot " + rec.Name + " taken.", nil
+}
+
+// snapshotAndApply applies a deployment after snapshotBeforeApply.
+func snapshotAndApply(ctx context.Context, cfg Config, tf TerraformRunner, dir string) terraformDoneMsg {
+	note, err := snapshotBeforeApply(cfg, dir)
+	if err != nil {
+		return terraformDoneMsg{err: err}
+	}
+	msg := applyAndReadOutputs(ctx, tf, dir)
+	msg.result = note + msg.result
+	return msg
+}
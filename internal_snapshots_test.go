@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc answers the requests of a proxmoxClient in tests.
+type roundTripFunc func(*http.Request) *http.Response
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req), nil
+}
+
+func TestTakeSnapshotsDiscardsOnFailure(t *testing.T) {
+	var calls []string
+	client := &proxmoxClient{apiURL: "pve", http: &http.Client{Transport: roundTripFunc(func(req *http.Request) *http.Response {
+		path := strings.TrimPrefix(req.URL.Path, "/api2/json")
+		calls = append(calls, req.Method+" "+path)
+		status, body := http.StatusOK, `{"data": "UPID:task"}`
+		switch {
+		case strings.Contains(path, "/tasks/"):
+			body = `{"data": {"status": "stopped", "exitstatus": "OK"}}`
+		case req.Method == "POST" && strings.Contains(path, "/qemu/103/"):
+			status, body = http.StatusInternalServerError, "snapshot failed"
+		}
+		return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}
+	})}}
+	vms := []ProxmoxVM{{VmID: 101, Node: "pve1"}, {VmID: 102, Node: "pve1"}, {VmID: 103, Node: "pve1"}}
+	rec, err := takeSnapshots(client, vms, SnapshotRecord{Name: "apply_1", Action: "apply"})
+	if err == nil || !strings.Contains(err.Error(), "VM 103") {
+		t.Fatalf("takeSnapshots() error = %v, want the failure of VM 103", err)
+	}
+	var deleted []string
+	for _, c := range calls {
+		if strings.HasPrefix(c, "DELETE ") {
+			deleted = append(deleted, c)
+		}
+	}
+	want := []string{"DELETE /nodes/pve1/qemu/101/snapshot/apply_1", "DELETE /nodes/pve1/qemu/102/snapshot/apply_1"}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted = %q, want %q", deleted, want)
+	}
+	if !reflect.DeepEqual(rec.VMIDs, []int{101, 102}) {
+		t.Errorf("VMIDs = %v, want [101 102]", rec.VMIDs)
+	}
+}
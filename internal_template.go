@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// templateSuffix marks template files that are rendered with text/template
+// instead of being copied verbatim. The suffix is dropped from the output name.
+const templateSuffix = ".tmpl"
+
+// defaultTemplateFields is the field set of templates that do not declare their own.
+var defaultTemplateFields = []string{
+	"vm_app", "platform_description", "zone", "platform_id", "vm_network_suffix", "vm_id_prefix",
+	"vm_memory", "vm_cpu_cores", "vm_disk_count", "vm_disk_size", "vm_count", "vm_template",
+	"cluster",
+}
+
+// TemplateSpec is one entry of the template catalog in config.yaml.
+type TemplateSpec struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Path        string   `yaml:"path"`
+	Provider    string   `yaml:"provider"` // app dir prefix, e.g. proxmox
+	Fields      []string `yaml:"fields"`   // form fields in display order
+
+	// Used when importing existing VMs; rendered with .Index, .VMID, .Node and .Name.
+	ImportAddress string `yaml:"import_address"` // default proxmox_vm_qemu.vm[{{ .Index }}]
+	ImportID      string `yaml:"import_id"`      // default {{ .Node }}/qemu/{{ .VMID }}
+}
+
+// templateCatalog returns the configured templates, or a single "default"
+// template built from template_path when no catalog is configured.
+func templateCatalog(cfg Config) []TemplateSpec {
+	specs := cfg.Templates
+	if len(specs) == 0 {
+		specs = []TemplateSpec{{Name: "default", Path: cfg.TemplatePath}}
+	}
+	out := make([]TemplateSpec, 0, len(specs))
+	for _, t := range specs {
+		if t.Provider == "" {
+			t.Provider = "proxmox"
+		}
+		if len(t.Fields) == 0 {
+			t.Fields = defaultTemplateFields
+		}
+		if t.Name == "" {
+			t.Name = filepath.Base(t.Path)
+		}
+		if t.ImportAddress == "" {
+			t.ImportAddress = "proxmox_vm_qemu.vm[{{ .Index }}]"
+		}
+		if t.ImportID == "" {
+			t.ImportID = "{{ .Node }}/qemu/{{ .VMID }}"
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// findTemplate returns the index of the named template, or -1.
+func findTemplate(templates []TemplateSpec, name string) int {
+	for i, t := range templates {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// TemplateData is the data every *.tmpl file of a Terraform template is rendered with.
+type TemplateData struct {
+	AppDir  string
+	Preset  string
+	Values  map[string]string // raw form values, keyed by tfvars name
+	Vars    map[string]string // HCL-formatted values as written to terraform.tfvars
+	Backend BackendConfig
+}
+
+// BackendConfig describes the remote state backend of a deployment.
+type BackendConfig struct {
+	Type    string
+	Bucket  string
+	Key     string
+	Region  string
+	Profile string
+}
+
+// defaultBackendTemplate is rendered into s3.tf when a template does not
+// declare a backend of its own.
+const defaultBackendTemplate = `terraform {
+  backend "s3" {
+    bucket          = "{{ .Backend.Bucket }}"
+    key             = "{{ .Backend.Key }}"
+    use_lockfile    = true
+    region          = "{{ .Backend.Region }}"
+    encrypt         = true{{ if .Backend.Profile }}
+    profile         = "{{ .Backend.Profile }}"{{ end }}
+  }
+}
+`
+
+var templateFuncs = template.FuncMap{
+	"quote":   func(s string) string { return fmt.Sprintf("%q", s) },
+	"hclList": hclList,
+	"default": func(def, s string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// hclList turns a comma-separated form value into an HCL list of strings.
+func hclList(s string) string {
+	arr := []string{}
+	for _, part := range strings.Split(s, ",") {
+		arr = append(arr, fmt.Sprintf("\"%s\"", strings.Trim(strings.TrimSpace(part), "\"")))
+	}
+	return "[" + strings.Join(arr, ", ") + "]"
+}
+
+func newBackendConfig(cfg Config, appDir string) BackendConfig {
+	backendType := cfg.BackendType
+	if backendType == "" {
+		backendType = "s3"
+	}
+	region := "ap-southeast-2"
+	if cfg.AWSRegion != "" {
+		region = cfg.AWSRegion
+	}
+	return BackendConfig{
+		Type:    backendType,
+		Bucket:  cfg.S3Bucket,
+		Key:     fmt.Sprintf("%s/s3/terraform.tfstate", appDir),
+		Region:  region,
+		Profile: cfg.AWSProfile,
+	}
+}
+
+func renderString(name, text string, data TemplateData) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("render %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderTemplateDir copies src to dst, rendering *.tmpl files with data on the way.
+// If the result does not declare a Terraform backend, the default S3 backend is
+// added; a declared backend must use the launcher's bucket and key. dst is
+// removed again if rendering fails.
+func renderTemplateDir(src, dst string, data TemplateData) error {
+	_, statErr := os.Stat(dst)
+	err := renderTemplateFiles(src, dst, data)
+	if err != nil && os.IsNotExist(statErr) {
+		_ = os.RemoveAll(dst)
+	}
+	return err
+}
+
+func renderTemplateFiles(src, dst string, data TemplateData) error {
+	if err := renderDir(src, dst, data); err != nil {
+		return err
+	}
+	keys, buckets, declared, err := backendSettings(dst)
+	if err != nil {
+		return err
+	}
+	if declared {
+		// Destroy, rename, reconcile and state unlocking all find the state
+		// at the default bucket and key, so a template may not move it
+		if len(keys) == 0 || len(buckets) == 0 {
+			return fmt.Errorf("the template's backend must set bucket and key ({{ .Backend.Bucket }}, {{ .Backend.Key }})")
+		}
+		for _, k := range keys {
+			if k != data.Backend.Key {
+				return fmt.Errorf("backend key %q must be %q ({{ .Backend.Key }})", k, data.Backend.Key)
+			}
+		}
+		for _, b := range buckets {
+			if b != data.Backend.Bucket {
+				return fmt.Errorf("backend bucket %q must be %q ({{ .Backend.Bucket }})", b, data.Backend.Bucket)
+			}
+		}
+		return nil
+	}
+	out, err := renderString("s3.tf", defaultBackendTemplate, data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dst, "s3.tf"), out, 0644)
+}
+
+func renderDir(src, dst string, data TemplateData) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		if entry.IsDir() {
+			if err := renderDir(srcPath, filepath.Join(dst, entry.Name()), data); err != nil {
+				return err
+			}
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(srcPath)
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if strings.HasSuffix(name, templateSuffix) {
+			name = strings.TrimSuffix(name, templateSuffix)
+			content, err = renderString(entry.Name(), string(content), data)
+			if err != nil {
+				return err
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dst, name), content, info.Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backendKeyRe and backendBucketRe match the key and bucket attributes of a backend block.
+var (
+	backendKeyRe    = regexp.MustCompile(`(?m)^\s*key\s*=\s*"([^"]*)"`)
+	backendBucketRe = regexp.MustCompile(`(?m)^\s*bucket\s*=\s*"([^"]*)"`)
+)
+
+// backendSettings reports whether any top-level *.tf file in dir has a
+// backend block, and the state keys and buckets set in those files.
+func backendSettings(dir string) (keys, buckets []string, declared bool, err error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	for _, path := range matches {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if !strings.Contains(string(content), "backend \"") {
+			continue
+		}
+		declared = true
+		for _, m := range backendKeyRe.FindAllStringSubmatch(string(content), -1) {
+			keys = append(keys, m[1])
+		}
+		for _, m := range backendBucketRe.FindAllStringSubmatch(string(content), -1) {
+			buckets = append(buckets, m[1])
+		}
+	}
+	return keys, buckets, declared, nil
+}
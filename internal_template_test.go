@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplateDir(t *testing.T) {
+	cfg := Config{S3Bucket: "states"}
+	data := TemplateData{
+		AppDir:  "proxmox_web_dmz_01",
+		Values:  map[string]string{"vm_app": "web"},
+		Backend: newBackendConfig(cfg, "proxmox_web_dmz_01"),
+	}
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+		want    map[string]string // file -> substring
+	}{
+		{
+			name:  "default backend",
+			files: map[string]string{"main.tf.tmpl": `app = {{ quote (index .Values "vm_app") }}`},
+			want:  map[string]string{"main.tf": `app = "web"`, "s3.tf": `key             = "proxmox_web_dmz_01/s3/terraform.tfstate"`},
+		},
+		{
+			name: "own backend with the launcher's bucket and key",
+			files: map[string]string{"backend.tf.tmpl": `terraform {
+  backend "s3" {
+    bucket = "{{ .Backend.Bucket }}"
+    key    = "{{ .Backend.Key }}"
+  }
+}`},
+			want: map[string]string{"backend.tf": `key    = "proxmox_web_dmz_01/s3/terraform.tfstate"`},
+		},
+		{
+			name: "own backend with another key",
+			files: map[string]string{"backend.tf.tmpl": `terraform {
+  backend "s3" {
+    bucket = "{{ .Backend.Bucket }}"
+    key    = "apps/{{ .AppDir }}.tfstate"
+  }
+}`},
+			wantErr: "must be",
+		},
+		{
+			name: "own backend with another bucket",
+			files: map[string]string{"backend.tf": `terraform {
+  backend "s3" {
+    bucket = "other"
+    key    = "proxmox_web_dmz_01/s3/terraform.tfstate"
+  }
+}`},
+			wantErr: "must be",
+		},
+		{
+			name:    "own backend without key",
+			files:   map[string]string{"backend.tf": "terraform {\n  backend \"s3\" {}\n}\n"},
+			wantErr: "must set bucket and key",
+		},
+		{
+			name:    "broken template",
+			files:   map[string]string{"main.tf.tmpl": `{{ .Nope`},
+			wantErr: "main.tf.tmpl",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := t.TempDir(), filepath.Join(t.TempDir(), data.AppDir)
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := renderTemplateDir(src, dst, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if _, err := os.Stat(dst); !os.IsNotExist(err) {
+					t.Errorf("failed render left %s behind", dst)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.want {
+				content, err := os.ReadFile(filepath.Join(dst, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(content), want) {
+					t.Errorf("%s =\n%s\nwant it to contain %q", name, content, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHCLList(t *testing.T) {
+	for in, want := range map[string]string{
+		"100G":              `["100G"]`,
+		"100G, 200G":        `["100G", "200G"]`,
+		`"100G","200G"`:     `["100G", "200G"]`,
+		" 1T ,  \"50G\"   ": `["1T", "50G"]`,
+	} {
+		if got := hclList(in); got != want {
+			t.Errorf("hclList(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestTemplateCatalog(t *testing.T) {
+	got := templateCatalog(Config{TemplatePath: "templates/vm"})
+	if len(got) != 1 || got[0].Name != "default" || got[0].Provider != "proxmox" || !reflect.DeepEqual(got[0].Fields, defaultTemplateFields) {
+		t.Errorf("templateCatalog() without templates = %+v, want a single default template", got)
+	}
+
+	cfg := Config{Templates: []TemplateSpec{
+		{Name: "k8s", Path: "templates/k8s", Provider: "pve", Fields: []string{"vm_app", "zone"}},
+		{Path: "templates/db"},
+	}}
+	got = templateCatalog(cfg)
+	if got[0].Provider != "pve" || !reflect.DeepEqual(got[0].Fields, []string{"vm_app", "zone"}) {
+		t.Errorf("declared values were replaced: %+v", got[0])
+	}
+	if got[1].Name != "db" || got[1].Provider != "proxmox" {
+		t.Errorf("defaults not applied: %+v", got[1])
+	}
+	if findTemplate(got, "db") != 1 || findTemplate(got, "missing") != -1 {
+		t.Error("findTemplate() did not find templates by name")
+	}
+}
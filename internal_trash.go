@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TrashEntry describes a destroyed deployment kept for recovery. Each entry
+// directory holds the deployment under deployment/ and the remote state
+// backup under remote-state/.
+type TrashEntry struct {
+	Name        string `yaml:"name"`
+	Original    string `yaml:"original"`
+	StatePrefix string `yaml:"state_prefix"`
+	DeletedAt   string `yaml:"deleted_at"`
+	ExpiresAt   string `yaml:"expires_at"`
+}
+
+func trashDir(cfg Config) string {
+	if cfg.TrashPath != "" {
+		return cfg.TrashPath
+	}
+	return filepath.Join(filepath.Dir(filepath.Clean(cfg.AppsPath)), ".launcher-trash")
+}
+
+func trashRetention(cfg Config) time.Duration {
+	days := cfg.TrashRetentionDays
+	if days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// protectionReason explains why a deployment must not be destroyed, or returns "".
+func protectionReason(cfg Config, dep deploymentInfo) string {
+	if dep.Protected {
+		return fmt.Sprintf("'%s' is marked protected", dep.Name)
+	}
+	vals, err := loadTfvars(filepath.Join(dep.Path, "terraform.tfvars"))
+	if err != nil {
+		return ""
+	}
+	zone := strings.Trim(vals["zone"], "\"")
+	for _, z := range cfg.ProtectedZones {
+		if z == zone {
+			return fmt.Sprintf("zone '%s' is protected by policy", zone)
+		}
+	}
+	return ""
+}
+
+func setDeploymentProtected(path string, protected bool) error {
+	meta, err := readDeploymentMeta(path)
+	if err != nil {
+		return err
+	}
+	meta.Protected = protected
+	return writeDeploymentMeta(path, meta)
+}
+
+// newTrashEntry creates the trash directory for a deployment about to be destroyed.
+func newTrashEntry(cfg Config, dep deploymentInfo) (string, error) {
+	now := time.Now().UTC()
+	entry := TrashEntry{
+		Name:        dep.Name,
+		Original:    dep.Path,
+		StatePrefix: dep.Name + "/",
+		DeletedAt:   now.Format(time.RFC3339),
+		ExpiresAt:   now.Add(trashRetention(cfg)).Format(time.RFC3339),
+	}
+	dir := filepath.Join(trashDir(cfg), fmt.Sprintf("%s__%s", dep.Name, now.Format("20060102T150405Z")))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	return dir, os.WriteFile(filepath.Join(dir, "trash.yaml"), data, 0644)
+}
+
+// moveDir renames src to dst, falling back to copy and delete across filesystems.
+func moveDir(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyDir(src, dst); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// destroyDeployment runs terraform destroy, backs up and removes the remote
+// state and moves the deployment directory into the trash.
+func destroyDeployment(ctx context.Context, tf TerraformRunner, cfg Config, dep deploymentInfo) (string, error) {
+	if reason := protectionReason(cfg, dep); reason != "" {
+		return "", fmt.Errorf("refusing to destroy: %s", reason)
+	}
+	// Everything the state backup needs is checked before anything is destroyed
+	if cfg.S3Bucket == "" {
+		return "", fmt.Errorf("refusing to destroy: s3_bucket is not set, so the remote state can't be backed up")
+	}
+	if _, err := exec.LookPath("aws"); err != nil {
+		return "", fmt.Errorf("refusing to destroy: the aws CLI is needed to back up the remote state: %w", err)
+	}
+	entry, err := newTrashEntry(cfg, dep)
+	if err != nil {
+		return "", fmt.Errorf("refusing to destroy: could not create trash entry: %w", err)
+	}
+	if err := runTerraformDestroy(ctx, tf, dep.Path); err != nil {
+		_ = os.RemoveAll(entry)
+		return "", err
+	}
+	_ = setDeploymentState(dep.Path, "DESTROYED", "destroy")
+	prefix := fmt.Sprintf("%s/", dep.Name)
+	if err := copyS3Prefix(cfg.S3Bucket, prefix, filepath.Join(entry, "remote-state"), cfg.AWSProfile, cfg.AWSRegion); err != nil {
+		return "", fmt.Errorf("destroyed, but remote state backup failed (state kept): %w", err)
+	}
+	if err := deleteS3Prefix(cfg.S3Bucket, prefix, cfg.AWSProfile, cfg.AWSRegion); err != nil {
+		return "", fmt.Errorf("destroyed, but remote state removal failed: %w", err)
+	}
+	if err := moveDir(dep.Path, filepath.Join(entry, "deployment")); err != nil {
+		return "", fmt.Errorf("destroyed, but failed to move directory to trash: %w", err)
+	}
+	return fmt.Sprintf("Destroyed: terraform + remote state + directory moved to %s.", entry), nil
+}
+
+// purgeTrash deletes trash entries whose retention period has passed.
+func purgeTrash(cfg Config) (int, error) {
+	entries, err := os.ReadDir(trashDir(cfg))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	purged := 0
+	now := time.Now().UTC()
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(trashDir(cfg), e.Name())
+		data, err := os.ReadFile(filepath.Join(dir, "trash.yaml"))
+		if err != nil {
+			continue
+		}
+		var entry TrashEntry
+		if err := yaml.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		expires, err := time.Parse(time.RFC3339, entry.ExpiresAt)
+		if err != nil || now.Before(expires) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProtectionReason(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte("zone = \"dmz\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		cfg  Config
+		dep  deploymentInfo
+		want string
+	}{
+		{"unprotected", Config{}, deploymentInfo{Name: "a", Path: dir}, ""},
+		{"marked protected", Config{}, deploymentInfo{Name: "a", Path: dir, Protected: true}, "marked protected"},
+		{"protected zone", Config{ProtectedZones: []string{"dmz"}}, deploymentInfo{Name: "a", Path: dir}, "zone 'dmz'"},
+		{"other zone", Config{ProtectedZones: []string{"admin"}}, deploymentInfo{Name: "a", Path: dir}, ""},
+	}
+	for _, tt := range tests {
+		got := protectionReason(tt.cfg, tt.dep)
+		if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+			t.Errorf("%s: protectionReason() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDestroyDeploymentChecksFirst(t *testing.T) {
+	apps := t.TempDir()
+	dep := deploymentInfo{Name: "proxmox_web_dev_01", Path: filepath.Join(apps, "proxmox_web_dev_01")}
+	if err := os.MkdirAll(dep.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		cfg  Config
+		dep  deploymentInfo
+		want string
+	}{
+		{"protected", Config{AppsPath: apps, S3Bucket: "states"}, deploymentInfo{Name: dep.Name, Path: dep.Path, Protected: true}, "protected"},
+		{"no bucket", Config{AppsPath: apps}, dep, "s3_bucket"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := &fakeRunner{}
+			_, err := destroyDeployment(context.Background(), tf, tt.cfg, tt.dep)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+			if len(tf.calls) > 0 {
+				t.Errorf("terraform ran %q before the destroy was refused", tf.calls)
+			}
+			if _, err := os.Stat(dep.Path); err != nil {
+				t.Errorf("deployment directory gone: %v", err)
+			}
+			if entries, _ := os.ReadDir(trashDir(tt.cfg)); len(entries) > 0 {
+				t.Errorf("trash entries left behind: %d", len(entries))
+			}
+		})
+	}
+}
+
+func TestPendingDestroyDeployment(t *testing.T) {
+	a := deploymentInfo{Name: "a", Path: "/apps/a"}
+	b := deploymentInfo{Name: "b", Path: "/apps/b"}
+	m := model{pendingDestroyName: "b", pendingDestroyPath: "/apps/b"}
+
+	// The table was reloaded and b moved to another row
+	m.deployments = []deploymentInfo{b, a}
+	if dep, ok := pendingDestroyDeployment(m); !ok || dep.Name != "b" {
+		t.Errorf("pendingDestroyDeployment() = %q, %v; want b", dep.Name, ok)
+	}
+	m.deployments = []deploymentInfo{a}
+	if _, ok := pendingDestroyDeployment(m); ok {
+		t.Error("pendingDestroyDeployment() found b after it was removed")
+	}
+	m.deployments = []deploymentInfo{{Name: "c", Path: "/apps/b"}}
+	if _, ok := pendingDestroyDeployment(m); ok {
+		t.Error("pendingDestroyDeployment() accepted a different name at b's path")
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// templateUpgrade is a pending three-way merge of template changes into a deployment.
+type templateUpgrade struct {
+	DeploymentPath string
+	FromVersion    string
+	ToVersion      string
+	Changes        []templateFileChange
+}
+
+type templateFileChange struct {
+	Path      string // relative to the deployment directory
+	Old       []byte
+	New       []byte
+	Mode      os.FileMode // permissions of the new file, from the template
+	Deleted   bool
+	Conflicts int
+}
+
+func (u *templateUpgrade) Conflicts() int {
+	n := 0
+	for _, c := range u.Changes {
+		n += c.Conflicts
+	}
+	return n
+}
+
+// Diff renders the pending changes as unified diffs.
+func (u *templateUpgrade) Diff() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Template %s → %s\n\n", shortVersion(u.FromVersion), shortVersion(u.ToVersion))
+	if strings.HasSuffix(u.FromVersion, dirtySuffix) {
+		b.WriteString("The deployment was rendered from uncommitted template changes; the merge base is their last commit, review the diff carefully.\n\n")
+	}
+	if len(u.Changes) == 0 {
+		b.WriteString("No file changes; only the recorded template version will be updated.\n")
+	}
+	for _, c := range u.Changes {
+		newName := "b/" + c.Path
+		if c.Deleted {
+			newName = "/dev/null"
+		}
+		if c.Conflicts > 0 {
+			fmt.Fprintf(&b, "!!! %s: %d conflict(s)\n", c.Path, c.Conflicts)
+		}
+		b.WriteString(unifiedDiff("a/"+c.Path, newName, string(c.Old), string(c.New)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func shortVersion(v string) string {
+	if strings.HasSuffix(v, dirtySuffix) {
+		return shortVersion(strings.TrimSuffix(v, dirtySuffix)) + dirtySuffix
+	}
+	if len(v) > 8 {
+		return v[:8]
+	}
+	if v == "" {
+		return "unknown"
+	}
+	return v
+}
+
+// isDeploymentLocalFile reports files that belong to the deployment and are never upgraded.
+func isDeploymentLocalFile(rel string) bool {
+	base := filepath.Base(rel)
+	return rel == "terraform.tfvars" ||
+		strings.HasPrefix(base, "launcher.") ||
+		strings.HasPrefix(rel, ".terraform"+string(filepath.Separator)) ||
+		base == ".terraform.lock.hcl" ||
+		strings.Contains(base, ".tfstate")
+}
+
+// tfvarsFormValue converts an HCL tfvars value back into the form representation.
+func tfvarsFormValue(v string) string {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+		var parts []string
+		for _, p := range strings.Split(strings.Trim(v, "[]"), ",") {
+			parts = append(parts, strings.Trim(strings.TrimSpace(p), "\""))
+		}
+		return strings.Join(parts, ",")
+	}
+	return strings.Trim(v, "\"")
+}
+
+// templateDataForDeployment rebuilds the render data of an existing deployment from its tfvars.
+func templateDataForDeployment(cfg Config, depPath string, meta DeploymentMeta) (TemplateData, error) {
+	vars, err := loadTfvars(filepath.Join(depPath, "terraform.tfvars"))
+	if err != nil {
+		return TemplateData{}, err
+	}
+	values := make(map[string]string, len(vars))
+	for k, v := range vars {
+		values[k] = tfvarsFormValue(v)
+	}
+	appDir := filepath.Base(depPath)
+	return TemplateData{
+		AppDir:  appDir,
+		Preset:  meta.Preset,
+		Values:  values,
+		Vars:    vars,
+		Backend: newBackendConfig(cfg, appDir),
+	}, nil
+}
+
+// readTree reads the files under root with their permissions, skipping .terraform.
+func readTree(root string) (map[string][]byte, map[string]os.FileMode, error) {
+	files := make(map[string][]byte)
+	modes := make(map[string]os.FileMode)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".terraform" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		files[rel], modes[rel] = content, info.Mode().Perm()
+		return nil
+	})
+	return files, modes, err
+}
+
+// renderTemplateVersion exports the template at rev and renders it with data
+// into a temp dir, returning the rendered files and their permissions.
+func renderTemplateVersion(spec TemplateSpec, rev string, data TemplateData) (map[string][]byte, map[string]os.FileMode, error) {
+	tmp, err := os.MkdirTemp("", "launcher-template-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(tmp)
+	src, dst := filepath.Join(tmp, "src"), filepath.Join(tmp, "out")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		return nil, nil, err
+	}
+	if err := exportTemplateVersion(spec.Path, rev, src); err != nil {
+		return nil, nil, err
+	}
+	if err := renderTemplateDir(src, dst, data); err != nil {
+		return nil, nil, err
+	}
+	return readTree(dst)
+}
+
+// planTemplateUpgrade merges the changes between the deployment's recorded template
+// version and the template's current version into the deployment, without writing anything.
+func planTemplateUpgrade(cfg Config, spec TemplateSpec, depPath string) (*templateUpgrade, error) {
+	meta, err := readDeploymentMeta(depPath)
+	if err != nil {
+		return nil, err
+	}
+	if meta.TemplateVersion == "" {
+		return nil, fmt.Errorf("no template version recorded for %s", filepath.Base(depPath))
+	}
+	current, err := templateVersion(spec.Path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read template version: %w", err)
+	}
+	if strings.HasSuffix(current, dirtySuffix) {
+		return nil, fmt.Errorf("template %s has uncommitted changes; commit them before upgrading", spec.Name)
+	}
+	data, err := templateDataForDeployment(cfg, depPath, meta)
+	if err != nil {
+		return nil, err
+	}
+	base, _, err := renderTemplateVersion(spec, meta.TemplateVersion, data)
+	if err != nil {
+		return nil, err
+	}
+	next, modes, err := renderTemplateVersion(spec, current, data)
+	if err != nil {
+		return nil, err
+	}
+	u := &templateUpgrade{DeploymentPath: depPath, FromVersion: meta.TemplateVersion, ToVersion: current}
+	paths := make(map[string]bool)
+	for p := range base {
+		paths[p] = true
+	}
+	for p := range next {
+		paths[p] = true
+	}
+	for _, rel := range sortedKeys(paths) {
+		if isDeploymentLocalFile(rel) {
+			continue
+		}
+		baseContent, inBase := base[rel]
+		newContent, inNew := next[rel]
+		if inBase == inNew && bytes.Equal(baseContent, newContent) {
+			continue
+		}
+		cur, err := os.ReadFile(filepath.Join(depPath, rel))
+		inCur := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		change := templateFileChange{Path: rel, Old: cur, Mode: modes[rel]}
+		switch {
+		case !inCur && inBase:
+			// Removed from the deployment on purpose; leave it out
+			continue
+		case !inCur:
+			change.New = newContent
+		case !inNew:
+			if !bytes.Equal(cur, baseContent) {
+				// Template dropped a file the deployment customised; keep it
+				continue
+			}
+			change.Deleted = true
+		case bytes.Equal(cur, baseContent):
+			change.New = newContent
+		default:
+			merged, conflicts, err := mergeFile(cur, baseContent, newContent)
+			if err != nil {
+				return nil, err
+			}
+			change.New, change.Conflicts = merged, conflicts
+		}
+		if inCur && !change.Deleted && bytes.Equal(cur, change.New) {
+			continue
+		}
+		u.Changes = append(u.Changes, change)
+	}
+	return u, nil
+}
+
+// applyTemplateUpgrade writes the merged files and records the new template version.
+func applyTemplateUpgrade(u *templateUpgrade) error {
+	if n := u.Conflicts(); n > 0 {
+		return fmt.Errorf("%d merge conflict(s); resolve them in the deployment manually", n)
+	}
+	for _, c := range u.Changes {
+		target := filepath.Join(u.DeploymentPath, c.Path)
+		if c.Deleted {
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		mode := c.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(target, c.New, mode); err != nil {
+			return err
+		}
+		// WriteFile keeps the permissions of an existing file
+		if err := os.Chmod(target, mode); err != nil {
+			return err
+		}
+	}
+	meta, err := readDeploymentMeta(u.DeploymentPath)
+	if err != nil {
+		return err
+	}
+	meta.TemplateVersion = u.ToVersion
+	return writeDeploymentMeta(u.DeploymentPath, meta)
+}
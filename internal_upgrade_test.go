@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeFile(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	base := "a\nb\nc\nd\ne\n"
+	tests := []struct {
+		name          string
+		ours, theirs  string
+		want          string
+		wantConflicts int
+	}{
+		{"only theirs", base, "a\nb\nc\nd\nE\n", "a\nb\nc\nd\nE\n", 0},
+		{"both, apart", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", 0},
+		{"both, same line", "a\nb\nours\nd\ne\n", "a\nb\ntheirs\nd\ne\n", "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts, err := mergeFile([]byte(tt.ours), []byte(base), []byte(tt.theirs))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if conflicts != tt.wantConflicts {
+				t.Errorf("conflicts = %d, want %d", conflicts, tt.wantConflicts)
+			}
+			if tt.wantConflicts == 0 && string(got) != tt.want {
+				t.Errorf("merged =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// gitRepo runs git in dir, failing the test on error.
+func gitRepo(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestTemplateUpgrade(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmplDir := t.TempDir()
+	write := func(dir, name, content string, mode os.FileMode) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(filepath.Join(dir, name), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRepo(t, tmplDir, "init", "-q")
+	write(tmplDir, "main.tf.tmpl", "# {{ .AppDir }}\nresource \"a\" \"b\" {}\n\nresource \"c\" \"d\" {}\n", 0644)
+	write(tmplDir, "hook.sh", "#!/bin/sh\necho v1\n", 0644)
+	gitRepo(t, tmplDir, "add", "-A")
+	gitRepo(t, tmplDir, "commit", "-q", "-m", "v1")
+
+	cfg := Config{S3Bucket: "states"}
+	spec := TemplateSpec{Name: "test", Path: tmplDir}
+	depPath := filepath.Join(t.TempDir(), "proxmox_web_dev_01")
+	data := TemplateData{AppDir: filepath.Base(depPath), Values: map[string]string{}, Backend: newBackendConfig(cfg, filepath.Base(depPath))}
+	if err := renderTemplateDir(tmplDir, depPath, data); err != nil {
+		t.Fatal(err)
+	}
+	write(depPath, "terraform.tfvars", "", 0644)
+	v1, err := templateVersion(tmplDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeDeploymentMeta(depPath, DeploymentMeta{Template: "test", TemplateVersion: v1}); err != nil {
+		t.Fatal(err)
+	}
+	// A local change the upgrade has to keep
+	write(depPath, "main.tf", "# "+data.AppDir+"\nresource \"a\" \"b\" { local = true }\n\nresource \"c\" \"d\" {}\n", 0644)
+
+	write(tmplDir, "main.tf.tmpl", "# {{ .AppDir }}\nresource \"a\" \"b\" {}\n\nresource \"c\" \"d\" { new = true }\n", 0644)
+	write(tmplDir, "hook.sh", "#!/bin/sh\necho v2\n", 0755)
+	write(tmplDir, "post.sh", "#!/bin/sh\n", 0755)
+	gitRepo(t, tmplDir, "add", "-A")
+	if _, err := planTemplateUpgrade(cfg, spec, depPath); err == nil || !strings.Contains(err.Error(), "uncommitted") {
+		t.Errorf("upgrade to a dirty template: err = %v, want uncommitted changes", err)
+	}
+	gitRepo(t, tmplDir, "commit", "-q", "-am", "v2")
+
+	u, err := planTemplateUpgrade(cfg, spec, depPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyTemplateUpgrade(u); err != nil {
+		t.Fatal(err)
+	}
+	main, _ := os.ReadFile(filepath.Join(depPath, "main.tf"))
+	if !strings.Contains(string(main), "local = true") || !strings.Contains(string(main), "new = true") {
+		t.Errorf("main.tf =\n%s\nwant both the local and the template change", main)
+	}
+	for _, name := range []string{"hook.sh", "post.sh"} {
+		info, err := os.Stat(filepath.Join(depPath, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0755 {
+			t.Errorf("%s mode = %v, want 0755", name, info.Mode().Perm())
+		}
+	}
+	meta, _ := readDeploymentMeta(depPath)
+	if v2, _ := templateVersion(tmplDir); meta.TemplateVersion != v2 {
+		t.Errorf("recorded version = %s, want %s", meta.TemplateVersion, v2)
+	}
+}
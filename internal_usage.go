@@ -0,0 +1,324 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// CostRates are monthly prices per unit of requested resources.
+type CostRates struct {
+	Core     float64 `yaml:"core"`      // per vCPU
+	MemoryGB float64 `yaml:"memory_gb"` // per GiB of memory
+	DiskGB   float64 `yaml:"disk_gb"`   // per GiB of disk
+}
+
+// CostTable is the optional costs section of the config. Rates of a cluster
+// listed under Clusters replace the default rates they set (non-zero).
+type CostTable struct {
+	Currency  string `yaml:"currency"`
+	CostRates `yaml:",inline"`
+	Clusters  map[string]CostRates `yaml:"clusters"`
+}
+
+// ratesFor returns the rates that apply to a cluster.
+func (t CostTable) ratesFor(cluster string) CostRates {
+	r := t.CostRates
+	if o, ok := t.Clusters[cluster]; ok {
+		if o.Core != 0 {
+			r.Core = o.Core
+		}
+		if o.MemoryGB != 0 {
+			r.MemoryGB = o.MemoryGB
+		}
+		if o.DiskGB != 0 {
+			r.DiskGB = o.DiskGB
+		}
+	}
+	return r
+}
+
+// Usage is the requested capacity of one or more deployments.
+type Usage struct {
+	VMs      int
+	Cores    int
+	MemoryMB int
+	DiskGB   float64
+	Cost     float64 // estimated per month, 0 without a cost table
+}
+
+func (u *Usage) add(o Usage) {
+	u.VMs += o.VMs
+	u.Cores += o.Cores
+	u.MemoryMB += o.MemoryMB
+	u.DiskGB += o.DiskGB
+	u.Cost += o.Cost
+}
+
+// deploymentUsage is the usage of a deployment with the keys it is grouped by.
+type deploymentUsage struct {
+	Dep     deploymentInfo
+	Cluster string
+	Zone    string
+	App     string
+	Usage
+}
+
+// usageDimensions are the keys the usage dashboard groups by.
+var usageDimensions = []string{"cluster", "zone", "app", "owner", "team"}
+
+func (d deploymentUsage) key(dimension string) string {
+	var k string
+	switch dimension {
+	case "cluster":
+		k = d.Cluster
+	case "zone":
+		k = d.Zone
+	case "app":
+		k = d.App
+	case "owner":
+		k = d.Dep.Owner
+	case "team":
+		k = d.Dep.Team
+	}
+	if k == "" {
+		return "(none)"
+	}
+	return k
+}
+
+// parseDiskGB reads a disk size such as 100G, 1T or 512M in GiB; a plain
+// number is GiB.
+func parseDiskGB(s string) (float64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	factor := 1.0
+	switch {
+	case strings.HasSuffix(s, "T"):
+		factor, s = 1024, strings.TrimSuffix(s, "T")
+	case strings.HasSuffix(s, "G"):
+		s = strings.TrimSuffix(s, "G")
+	case strings.HasSuffix(s, "M"):
+		factor, s = 1.0/1024, strings.TrimSuffix(s, "M")
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid disk size %q", s)
+	}
+	return n * factor, nil
+}
+
+// vmDiskGB returns the disk of one VM: vm_disk_count disks sized by
+// vm_disk_size, the last size repeating if the list is shorter.
+func vmDiskGB(values map[string]string) (float64, error) {
+	var sizes []string
+	for _, s := range strings.Split(values["vm_disk_size"], ",") {
+		if strings.TrimSpace(s) != "" {
+			sizes = append(sizes, s)
+		}
+	}
+	if len(sizes) == 0 {
+		return 0, nil
+	}
+	count := len(sizes)
+	if v := values["vm_disk_count"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid vm_disk_count %q", v)
+		}
+		count = n
+	}
+	total := 0.0
+	for i := 0; i < count; i++ {
+		gb, err := parseDiskGB(sizes[min(i, len(sizes)-1)])
+		if err != nil {
+			return 0, err
+		}
+		total += gb
+	}
+	return total, nil
+}
+
+// usageOf computes what a deployment requests from its tfvars: vm_count
+// times vm_cpu_cores, vm_memory and the VM's disks.
+func usageOf(costs *CostTable, dep deploymentInfo) (deploymentUsage, error) {
+	d := deploymentUsage{Dep: dep}
+	values, err := deploymentFormValues(dep.Path)
+	if err != nil {
+		return d, err
+	}
+	d.Cluster, d.Zone, d.App = values["cluster"], values["zone"], values["vm_app"]
+	number := func(key string, def int) (int, error) {
+		v := values[key]
+		if v == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", key, v)
+		}
+		return n, nil
+	}
+	count, err := number("vm_count", 1)
+	if err != nil {
+		return d, err
+	}
+	cores, err := number("vm_cpu_cores", 0)
+	if err != nil {
+		return d, err
+	}
+	mem, err := number("vm_memory", 0)
+	if err != nil {
+		return d, err
+	}
+	disk, err := vmDiskGB(values)
+	if err != nil {
+		return d, err
+	}
+	d.VMs, d.Cores, d.MemoryMB, d.DiskGB = count, count*cores, count*mem, float64(count)*disk
+	if costs != nil {
+		r := costs.ratesFor(d.Cluster)
+		d.Cost = float64(d.Cores)*r.Core + float64(d.MemoryMB)/1024*r.MemoryGB + d.DiskGB*r.DiskGB
+	}
+	return d, nil
+}
+
+// usageStates are the deployment states whose VMs exist and count as usage.
+var usageStates = map[string]bool{"DEPLOYED": true, "ROLLED_BACK": true, "IMPORTING": true}
+
+// collectUsage computes the usage of the deployed deployments. The others
+// (never applied, cancelled, destroyed) are listed in notDeployed; those
+// whose tfvars can't be read are reported in problems. Both are left out.
+func collectUsage(costs *CostTable, infos []deploymentInfo) (rows []deploymentUsage, notDeployed, problems []string) {
+	for _, dep := range infos {
+		if !usageStates[dep.State] {
+			notDeployed = append(notDeployed, dep.Name)
+			continue
+		}
+		d, err := usageOf(costs, dep)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", dep.Name, err))
+			continue
+		}
+		rows = append(rows, d)
+	}
+	return rows, notDeployed, problems
+}
+
+// usageGroup is the usage of the deployments sharing a key.
+type usageGroup struct {
+	Key         string
+	Deployments []deploymentUsage
+	Usage
+}
+
+// groupUsage sums usage by a dimension, largest cost (or vCPU count) first.
+func groupUsage(rows []deploymentUsage, dimension string) []usageGroup {
+	index := make(map[string]int)
+	var groups []usageGroup
+	for _, d := range rows {
+		k := d.key(dimension)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, usageGroup{Key: k})
+		}
+		groups[i].Deployments = append(groups[i].Deployments, d)
+		groups[i].add(d.Usage)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Cost != groups[j].Cost {
+			return groups[i].Cost > groups[j].Cost
+		}
+		if groups[i].Cores != groups[j].Cores {
+			return groups[i].Cores > groups[j].Cores
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+func totalUsage(rows []deploymentUsage) Usage {
+	var u Usage
+	for _, d := range rows {
+		u.add(d.Usage)
+	}
+	return u
+}
+
+// formatCost renders a monthly cost, or "-" without a cost table.
+func formatCost(costs *CostTable, cost float64) string {
+	if costs == nil {
+		return "-"
+	}
+	s := fmt.Sprintf("%.2f", math.Round(cost*100)/100)
+	if costs.Currency != "" {
+		s += " " + costs.Currency
+	}
+	return s
+}
+
+func formatMemGB(mb int) string {
+	return fmt.Sprintf("%.1f", float64(mb)/1024)
+}
+
+// runUsage implements `launcher usage [--by dimension]`, printing requested
+// resources and estimated monthly cost grouped by cluster, zone, app, owner
+// or team.
+func runUsage(cfg Config, args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	by := fs.String("by", "cluster", "group by "+strings.Join(usageDimensions, ", "))
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if indexOf(*by, usageDimensions) < 0 {
+		fmt.Fprintf(stdout, "ERROR: cannot group by %q (available: %s)\n", *by, strings.Join(usageDimensions, ", "))
+		return 2
+	}
+	infos, err := listDeployments(cfg.AppsPath)
+	if err != nil {
+		fmt.Fprintln(stdout, "ERROR: could not list deployments:", err)
+		return 1
+	}
+	rows, notDeployed, problems := collectUsage(cfg.Costs, infos)
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tDEPLOYMENTS\tVMS\tVCPU\tMEMORY GIB\tDISK GIB\tCOST/MONTH\n", strings.ToUpper(*by))
+	for _, g := range groupUsage(rows, *by) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%.0f\t%s\n", g.Key, len(g.Deployments), g.VMs, g.Cores, formatMemGB(g.MemoryMB), g.DiskGB, formatCost(cfg.Costs, g.Cost))
+	}
+	t := totalUsage(rows)
+	fmt.Fprintf(w, "TOTAL\t%d\t%d\t%d\t%s\t%.0f\t%s\n", len(rows), t.VMs, t.Cores, formatMemGB(t.MemoryMB), t.DiskGB, formatCost(cfg.Costs, t.Cost))
+	w.Flush()
+	if len(notDeployed) > 0 {
+		fmt.Fprintln(stdout, "not deployed, not counted:", strings.Join(notDeployed, ", "))
+	}
+	for _, p := range problems {
+		fmt.Fprintln(stdout, "skipped", p)
+	}
+	return 0
+}
+
+// usageDetail lists the deployments of a group with their usage.
+func usageDetail(costs *CostTable, g usageGroup) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "DEPLOYMENT\tSTATE\tCLUSTER\tOWNER\tVMS\tVCPU\tMEMORY GIB\tDISK GIB\tCOST/MONTH")
+	sorted := append([]deploymentUsage(nil), g.Deployments...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Cost > sorted[j].Cost })
+	for _, d := range sorted {
+		state := d.Dep.State
+		if d.Dep.Approval != "" {
+			state += " (change " + d.Dep.Approval + ")" // counted at the proposed tfvars
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%.0f\t%s\n", d.Dep.Name, state, d.Cluster, d.Dep.Owner,
+			d.VMs, d.Cores, formatMemGB(d.MemoryMB), d.DiskGB, formatCost(costs, d.Cost))
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDiskGB(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{"100G", 100, false},
+		{"100g", 100, false},
+		{" 50 ", 50, false},
+		{"1T", 1024, false},
+		{"1.5T", 1536, false},
+		{"512M", 0.5, false},
+		{"", 0, true},
+		{"10GB", 0, true},
+		{"lots", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDiskGB(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDiskGB(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDiskGB(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUsageOf(t *testing.T) {
+	costs := &CostTable{
+		CostRates: CostRates{Core: 10, MemoryGB: 5, DiskGB: 0.1},
+		Clusters:  map[string]CostRates{"pve2": {Core: 20}},
+	}
+	tests := []struct {
+		name    string
+		tfvars  string
+		costs   *CostTable
+		want    Usage
+		wantErr bool
+	}{
+		{
+			name:   "count times size",
+			tfvars: "cluster = \"pve1\"\nvm_count = 3\nvm_cpu_cores = 2\nvm_memory = 4096\nvm_disk_size = [\"100G\", \"50G\"]\n",
+			want:   Usage{VMs: 3, Cores: 6, MemoryMB: 12288, DiskGB: 450},
+		},
+		{
+			name:   "vm_count defaults to one",
+			tfvars: "cluster = \"pve1\"\nvm_cpu_cores = 4\nvm_memory = 2048\n",
+			want:   Usage{VMs: 1, Cores: 4, MemoryMB: 2048},
+		},
+		{
+			name:   "last disk size repeats",
+			tfvars: "cluster = \"pve1\"\nvm_count = 2\nvm_disk_count = 3\nvm_disk_size = [\"1T\", \"100G\"]\n",
+			want:   Usage{VMs: 2, DiskGB: 2 * (1024 + 100 + 100)},
+		},
+		{
+			name:   "default rates",
+			tfvars: "cluster = \"pve1\"\nvm_count = 2\nvm_cpu_cores = 2\nvm_memory = 2048\nvm_disk_size = [\"100G\"]\n",
+			costs:  costs,
+			want:   Usage{VMs: 2, Cores: 4, MemoryMB: 4096, DiskGB: 200, Cost: 4*10 + 4*5 + 200*0.1},
+		},
+		{
+			name:   "cluster rates",
+			tfvars: "cluster = \"pve2\"\nvm_count = 1\nvm_cpu_cores = 2\nvm_memory = 1024\n",
+			costs:  costs,
+			want:   Usage{VMs: 1, Cores: 2, MemoryMB: 1024, Cost: 2*20 + 1*5},
+		},
+		{
+			name:    "invalid number",
+			tfvars:  "vm_count = many\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid disk size",
+			tfvars:  "vm_disk_size = [\"big\"]\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte(tt.tfvars), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := usageOf(tt.costs, deploymentInfo{Name: "dep", Path: dir})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && got.Usage != tt.want {
+				t.Errorf("usageOf() = %+v, want %+v", got.Usage, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectUsageSkipsUndeployed(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte("vm_count = 1\nvm_cpu_cores = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var infos []deploymentInfo
+	for _, state := range []string{"DEPLOYED", "READY", "DESTROYED", "ROLLED_BACK", "CANCELLED"} {
+		infos = append(infos, deploymentInfo{Name: state, State: state, Path: dir})
+	}
+	rows, notDeployed, problems := collectUsage(nil, infos)
+	if len(rows) != 2 || len(notDeployed) != 3 || len(problems) != 0 {
+		t.Errorf("collectUsage() = %d rows, not deployed %q, problems %q; want 2 rows and 3 not deployed", len(rows), notDeployed, problems)
+	}
+}
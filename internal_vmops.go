@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// powerActions are the Proxmox status endpoints offered in the VM scene.
+var powerActions = map[string]string{
+	"s": "start",
+	"h": "shutdown",
+	"b": "reboot",
+	"x": "stop", // hard stop; k is taken by table navigation
+}
+
+// deploymentVMs returns a client for the deployment's cluster and the VMs it owns.
+func deploymentVMs(dep deploymentInfo) (*proxmoxClient, []ProxmoxVM, error) {
+	owner := newVMOwner(dep)
+	if owner.cluster == "" {
+		return nil, nil, fmt.Errorf("'%s' has no cluster in its tfvars", dep.Name)
+	}
+	client, err := proxmoxClientForCluster(owner.cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	vms, err := client.listVMs()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list Proxmox VMs: %w", err)
+	}
+	var owned []ProxmoxVM
+	for _, vm := range vms {
+		if vm.Template != 1 && owner.owns(owner.cluster, vm.VmID) {
+			owned = append(owned, vm)
+		}
+	}
+	return client, owned, nil
+}
+
+// exclusiveDeploymentVMs is deploymentVMs for actions that change VMs: it
+// fails when another deployment also owns one of them.
+func exclusiveDeploymentVMs(dep deploymentInfo) (*proxmoxClient, []ProxmoxVM, error) {
+	client, vms, err := deploymentVMs(dep)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]int, len(vms))
+	for i, vm := range vms {
+		ids[i] = vm.VmID
+	}
+	if err := checkSoleOwner(dep, newVMOwner(dep).cluster, ids); err != nil {
+		return nil, nil, err
+	}
+	return client, vms, nil
+}
+
+// fetchVMStatuses reads the live status of every VM of a deployment.
+func fetchVMStatuses(dep deploymentInfo) ([]VMStatus, error) {
+	client, vms, err := deploymentVMs(dep)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]VMStatus, 0, len(vms))
+	for _, vm := range vms {
+		st, err := client.vmStatus(vm.Node, vm.VmID)
+		if err != nil {
+			return nil, fmt.Errorf("status of VM %d: %w", vm.VmID, err)
+		}
+		st.VmID, st.Name = vm.VmID, vm.Name
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// powerVMs runs a power action on the given VMs of a deployment and waits
+// for each Proxmox task to finish.
+func powerVMs(dep deploymentInfo, vms []VMStatus, action string) (string, error) {
+	cluster := newVMOwner(dep).cluster
+	ids := make([]int, len(vms))
+	for i, vm := range vms {
+		ids[i] = vm.VmID
+	}
+	if err := checkSoleOwner(dep, cluster, ids); err != nil {
+		return "", fmt.Errorf("%s refused: %w", action, err)
+	}
+	client, err := proxmoxClientForCluster(cluster)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, vm := range vms {
+		upid, err := client.vmPower(vm.Node, vm.VmID, action)
+		if err != nil {
+			return "", fmt.Errorf("%s of VM %d failed: %w", action, vm.VmID, err)
+		}
+		if err := client.waitTask(vm.Node, upid, 2*time.Minute); err != nil {
+			return "", fmt.Errorf("%s of VM %d: %w", action, vm.VmID, err)
+		}
+		names = append(names, vm.Name)
+	}
+	return fmt.Sprintf("%s done: %s.", action, strings.Join(names, ", ")), nil
+}
+
+// formatUptime renders seconds as e.g. 3d4h, 5h12m or 42m.
+func formatUptime(seconds int64) string {
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case seconds <= 0:
+		return "-"
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// formatGiB renders a byte count in GiB with one decimal.
+func formatGiB(b int64) string {
+	return fmt.Sprintf("%.1f", float64(b)/(1<<30))
+}
+
+// vmStatusLine is the one-line summary of a VM used in the details panel.
+func vmStatusLine(st VMStatus) string {
+	return fmt.Sprintf("%-20s %-8s up %-7s cpu %3.0f%%  mem %s/%s GiB",
+		st.Name, st.Status, formatUptime(st.Uptime), st.CPU*100, formatGiB(st.Mem), formatGiB(st.MaxMem))
+}
@@ -1,17 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const uiWidth = 160
@@ -85,12 +89,28 @@ const (
 	sceneEditTable
 	sceneEditForm
 	sceneConfirmDestroy
+	sceneTemplateUpgrade
+	sceneLogList
+	sceneLogView
+	sceneOutputs
+	sceneImport
+	sceneReconcile
+	sceneVMs
+	sceneSnapshots
+	sceneHistory
+	sceneRename
+	sceneMetadata
+	sceneApprovals
+	sceneAudit
+	sceneUsage
 )
 
 type model struct {
 	cfg           Config
 	presets       []Preset
 	presetIdx     int
+	tfTemplates   []TemplateSpec
+	tfTemplateIdx int
 	fieldMeta     map[string]FieldMeta
 	helpText      string
 	currentScene  scene
@@ -99,8 +119,16 @@ type model struct {
 	createInputs []textinput.Model
 	createLabels []string
 	createFocus  int
+	cloneSource  string // deployment the create form was cloned from
+	createTTL    int    // days, one of ttlOptions; 0 for no expiry
+	createAck    string // form values whose policy warnings were acknowledged
 
-	deployments []deploymentInfo
+	policy Policy
+
+	deployments    []deploymentInfo // filtered by filterInput; the table rows
+	allDeployments []deploymentInfo
+	filterInput    textinput.Model
+	filtering      bool // typing goes to filterInput
 
 	editStatus string
 
@@ -108,6 +136,11 @@ type model struct {
 	editFormLabels []string
 	editFormPath   string
 	editFocusIndex int
+	editOriginal   []string // values loaded into the edit form
+	editConfirm    bool     // pending changes are shown and await Y
+	editPlan       *PlanSummary
+	editPlanFor    string // changesKey of the changes editPlan was made for
+	editPlanView   viewport.Model
 
 	gitStatus   string
 	awsStatus   string
@@ -121,16 +154,113 @@ type model struct {
 	isFetchingTemplates bool
 
 	// --- NEW FIELDS ---
-	isBusy bool
+	isBusy       bool
+	tf           TerraformRunner
+	cancelRun    context.CancelFunc
+	quitWhenDone bool // quit once the cancelled run has stopped
+
+	// Template upgrade preview
+	pendingUpgrade *templateUpgrade
+	upgradeView    viewport.Model
+
+	// Run log browser
+	logDeployment string
+	logRuns       []runLog
+	logTable      table.Model
+	logView       viewport.Model
+	logContent    []string // raw lines, ANSI colors preserved
+	logSearch     textinput.Model
+	logSearching  bool
+	logMatches    []int
+	logMatchIdx   int
+
+	// Terraform outputs viewer
+	outputsDeployment string
+	outputNames       []string
+	outputsCache      cachedOutputs
+	outputsRevealed   map[string]string // sensitive values fetched with [V], never cached
+	outputsTable      table.Model
+
+	// Import of existing Proxmox VMs
+	importCluster  string
+	importVMs      []ProxmoxVM
+	importSelected map[int]bool // by VMID
+	importTable    table.Model
+	importInputs   []textinput.Model // importLabels
+	importFocus    int               // 0 is the VM table, then the inputs
+
+	// Orphan reconciliation
+	reconcile        reconcileReport
+	reconcileTable   table.Model
+	reconcileConfirm bool // clean up of the selected orphan awaits Y
+
+	// Live VM status and power operations
+	vmStatuses   map[string][]VMStatus // by deployment path
+	vmStatusErrs map[string]string
+	vmMetrics    map[string][]VMMetrics // by deployment path
+	vmDeployment deploymentInfo
+	vmTable      table.Model
+	pendingPower string // power action awaiting Y
+	powerAll     bool   // power actions target all VMs instead of the selected one
+	powerRunning bool
+
+	// Snapshots of a deployment's VMs
+	snapshotDeployment deploymentInfo
+	snapshots          []SnapshotRecord
+	snapshotTable      table.Model
+	pendingSnapshotOp  string // rollback or delete awaiting Y
+	snapshotRunning    bool
+
+	// Tfvars history
+	historyDeployment string
+	history           []HistoryEntry // newest first
+	historyTable      table.Model
+	historyView       viewport.Model
+	historyMark       int // version marked for comparison, 0 for none
+	pendingRevert     bool
+
+	// Ownership metadata form
+	metaDeployment deploymentInfo
+	metaInputs     []textinput.Model // metaFormFields
+	metaFocus      int
+
+	// Change requests awaiting or holding approval
+	approvals     []pendingChange
+	approvalTable table.Model
+	approvalView  viewport.Model
+
+	// Audit log, newest first
+	auditEntries []AuditEntry
+	auditTable   table.Model
+	auditView    viewport.Model
+
+	// Usage dashboard
+	usageRows   []deploymentUsage
+	usageGroups []usageGroup
+	usageDim    int // index in usageDimensions
+	usageTable  table.Model
+	usageView   viewport.Model
+
+	// Rename with state migration
+	renameDeployment deploymentInfo
+	renameProvider   string
+	renameZone       string
+	renamePlatformID textinput.Model
+	renameConfirm    bool
 
 	// Destroy confirmation
-	pendingDestroyIdx  int
-	pendingDestroyName string
-	pendingDestroyPath string
+	pendingDestroyName  string
+	pendingDestroyPath  string
+	destroyConfirmInput textinput.Model
+	destroyPlanView     viewport.Model
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	tick := metricsTickCmd(metricsInterval(m.cfg))
+	if len(m.deployments) > 0 {
+		return tea.Batch(fetchVMStatusCmd(m.deployments[0]), fetchVMMetricsCmd(m.deployments[0]), tick)
+	}
+	return tick
 }
 
 func main() {
@@ -150,6 +280,28 @@ func main() {
 			}
 		}
 	}
+	if cfg.TerraformVersion != "" {
+		if _, err := checkTerraformVersion(cfg.TerraformBinary, cfg.TerraformVersion); err != nil {
+			fmt.Println("ERROR: terraform version check failed:", err)
+			os.Exit(1)
+		}
+	}
+	// Headless commands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reap":
+			os.Exit(runReap(cfg, os.Args[2:], os.Stdout))
+		case "policy-check":
+			os.Exit(runPolicyCheck(cfg, os.Args[2:], os.Stdout))
+		case "audit-export":
+			os.Exit(runAuditExport(cfg, os.Args[2:], os.Stdout))
+		case "usage":
+			os.Exit(runUsage(cfg, os.Args[2:], os.Stdout))
+		default:
+			fmt.Printf("ERROR: unknown command %q (available: reap, policy-check, audit-export, usage)\n", os.Args[1])
+			os.Exit(2)
+		}
+	}
 	presets, err := loadPresets(cfg.PresetsPath)
 	if err != nil {
 		fmt.Println("ERROR: could not load presets from presets dir:", err)
@@ -164,34 +316,32 @@ func main() {
 		fmt.Println("ERROR: could not load fields.yaml:", err)
 		os.Exit(1)
 	}
+	policy, err := loadPolicy(policyPath(cfg))
+	if err != nil {
+		fmt.Println("ERROR: could not load policy:", err)
+		os.Exit(1)
+	}
 	m := initialModel(cfg, presets, fieldMeta)
+	m.policy = policy
 	if _, err := tea.NewProgram(m).Run(); err != nil {
 		log.Fatal(err)
 	}
 }
 
 func initialModel(cfg Config, presets []Preset, fieldMeta map[string]FieldMeta) model {
-	labels := []string{
-		"vm_app", "platform_description", "zone", "platform_id", "vm_network_suffix", "vm_id_prefix",
-		"vm_memory", "vm_cpu_cores", "vm_disk_count", "vm_disk_size", "vm_count", "vm_template",
-		"cluster",
+	templates := templateCatalog(cfg)
+	templateIdx := 0
+	if i := findTemplate(templates, presets[0].Template); i >= 0 {
+		templateIdx = i
 	}
+	labels := templates[templateIdx].Fields
 
 	// Deployments table
-	deployCols := []table.Column{
-		{Title: "Name", Width: 24},
-		{Title: "Description", Width: 32},
-		{Title: "State", Width: 13},
-		{Title: "Last Action", Width: 20},
-	}
 	deployInfos, _ := listDeployments(cfg.AppsPath)
-	deployRows := make([]table.Row, len(deployInfos))
-	for i, info := range deployInfos {
-		deployRows[i] = table.Row{info.Name, info.Description, info.State, info.LastAction}
-	}
+	markOutdatedTemplates(deployInfos, templates)
 	deployTable := table.New(
-		table.WithColumns(deployCols),
-		table.WithRows(deployRows),
+		table.WithColumns(deploymentColumns()),
+		table.WithRows(deploymentRows(deployInfos)),
 		table.WithFocused(true),
 	)
 	deployTable.SetHeight(20)
@@ -203,51 +353,34 @@ func initialModel(cfg Config, presets []Preset, fieldMeta map[string]FieldMeta)
 		fieldMeta,
 	)
 
-	inputs := make([]textinput.Model, len(labels))
-	presetIdx := 0
-	for i, name := range labels {
-		ti := textinput.New()
-		ti.Placeholder = name
-		if val, ok := presets[presetIdx].Values[name]; ok {
-			switch v := val.(type) {
-			case string:
-				ti.SetValue(v)
-			case int:
-				ti.SetValue(fmt.Sprintf("%d", v))
-			case []interface{}:
-				strs := []string{}
-				for _, e := range v {
-					strs = append(strs, fmt.Sprintf("%v", e))
-				}
-				ti.SetValue(strings.Join(strs, ","))
-			default:
-				ti.SetValue(fmt.Sprintf("%v", v))
-			}
-		}
-		inputs[i] = ti
-	}
-	// === ADD THIS SNIPPET BELOW ===
-	templateIdx := indexOf("vm_template", labels)
-	if templateIdx >= 0 {
-		inputs[templateIdx].Width = 40 // pick the width you want!
-	}
-	// ==============================
-	inputs[0].Focus()
-
 	m := model{
 		cfg:            cfg,
 		presets:        presets,
 		presetIdx:      0,
+		tfTemplates:    templates,
+		tfTemplateIdx:  templateIdx,
 		currentScene:   sceneLauncher,
-		createInputs:   inputs,
+		createInputs:   newCreateInputs(labels),
 		createLabels:   labels,
 		createFocus:    0,
 		fieldMeta:      fieldMeta,
 		helpText:       "",
 		editFormLabels: []string{"vm_cpu_cores", "vm_memory", "vm_count", "vm_disk_count", "vm_disk_size"},
 		deployments:    deployInfos,
+		allDeployments: deployInfos,
+		filterInput:    textinput.New(),
 		deployTable:    deployTable,
 		tfvarsTable:    tfvarsTable,
+		tf:             newTerraformRunner(cfg),
+		vmStatuses:     make(map[string][]VMStatus),
+		vmStatusErrs:   make(map[string]string),
+		vmMetrics:      make(map[string][]VMMetrics),
+	}
+	m = applyPresetToForm(m, 0)
+	if n, err := purgeTrash(cfg); err != nil {
+		m.statusMessage = "Trash cleanup failed: " + err.Error()
+	} else if n > 0 {
+		m.statusMessage = fmt.Sprintf("Purged %d expired trash entr(ies).", n)
 	}
 
 	updateStatusBars(&m) // ← THIS IS ALL YOU NEED
@@ -276,7 +409,7 @@ func (m model) View() string {
 		col1Width := 89
 		col2Width := 68
 		// Render non-scrollable details for the selected deployment
-		detailsStr := renderDetailsPanel(m.cfg.AppsPath, m.deployments, selected, m.fieldMeta, col2Width, 20)
+		detailsStr := renderDetailsPanel(m.cfg.AppsPath, m.deployments, selected, m.fieldMeta, m.vmStatusSection(selected), col2Width, 26)
 		lines1 := strings.Split(deployTableStr, "\n")
 		lines2 := strings.Split(detailsStr, "\n")
 		maxLines := max(len(lines1), len(lines2))
@@ -291,14 +424,22 @@ func (m model) View() string {
 			out += padRight(lines1[i], col1Width) + " │ " + padRight(lines2[i], col2Width) + "\n"
 		}
 		body = out
+		if m.filtering || m.filterInput.Value() != "" {
+			body = fmt.Sprintf(" Filter: %s  (%d of %d)\n", m.filterInput.View(), len(m.deployments), len(m.allDeployments)) + body
+		}
 		tooltip = tooltipStyle.Render(m.statusMessage)
 	case sceneCreateForm:
-		body += tooltipStyle.Render(fmt.Sprintf("[Preset: %s] (F2/F3 to switch)", m.presets[m.presetIdx].Name))
+		tmpl := m.tfTemplates[m.tfTemplateIdx]
+		body += tooltipStyle.Render(fmt.Sprintf("[Template: %s] (F4/F5 to switch)  [Preset: %s] (F2/F3 to switch)  [TTL: %s] (F6/F7)  %s",
+			tmpl.Name, m.presets[m.presetIdx].Name, formatTTL(m.createTTL), tmpl.Description))
+		if m.cloneSource != "" {
+			body += "\n" + tooltipStyle.Render(fmt.Sprintf("Cloning '%s': platform ID, network suffix and VMID prefix were moved to the next free values.", m.cloneSource))
+		}
 		body += "\n" + " " + strings.Repeat("─", uiWidth-4) + "\n"
 		for i, ti := range m.createInputs {
 			cursor := " "
 			isFocused := i == m.createFocus
-			label := m.fieldMeta[m.createLabels[i]].Label
+			label := fieldLabel(m.fieldMeta, m.createLabels[i])
 			val := ti.Value()
 			display := padRight(val, 38)
 			field := ""
@@ -309,22 +450,43 @@ func (m model) View() string {
 			}
 			body += field + "\n"
 		}
+		if m.statusMessage != "" {
+			body += "\n" + diffWarnStyle.Render(m.statusMessage) + "\n"
+		}
 		tooltip = tooltipStyle.Render(m.fieldMeta[m.createLabels[m.createFocus]].Help)
 	case sceneEditForm:
 		for i, ti := range m.editFormInputs {
 			cursor := " "
 			isFocused := i == m.editFocusIndex
-			label := m.fieldMeta[m.editFormLabels[i]].Label
+			label := fieldLabel(m.fieldMeta, m.editFormLabels[i])
 			val := ti.Value()
 			display := padRight(val, 38)
+			changed := val != m.editOriginal[i]
+			if changed {
+				cursor = "*"
+			}
 			field := ""
 			if isFocused {
 				field = focusedStyle.Render(fmt.Sprintf("%s %-25s: > %s", cursor, label, display))
+			} else if changed {
+				field = diffAddStyle.Render(fmt.Sprintf("%s %-25s: > %s", cursor, label, display))
 			} else {
 				field = normalStyle.Render(fmt.Sprintf("%s %-25s: > %s", cursor, label, display))
 			}
+			if changed {
+				field += diffDelStyle.Render("  was " + m.editOriginal[i])
+				if m.fieldMeta[m.editFormLabels[i]].Impact == impactReplace {
+					field += diffWarnStyle.Render("  ⚠ forces replacement")
+				}
+			}
 			body += field + "\n"
 		}
+		if m.editPlan != nil {
+			if m.editPlanFor != changesKey(editChanges(m)) {
+				body += diffWarnStyle.Render(" Plan is out of date; press Ctrl+P to plan again.") + "\n"
+			}
+			body += tooltipStyle.Render(m.editPlanView.View()) + "\n"
+		}
 		if m.editStatus != "" {
 			tooltip = tooltipStyle.Render(m.editStatus)
 		} else {
@@ -334,11 +496,133 @@ func (m model) View() string {
 		// Confirmation view
 		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("196")).Render("Confirm Destroy")
 		body = "\n" + boxSection(centerText(title, uiWidth-4)) + "\n"
-		list := fmt.Sprintf("1) terraform destroy\n2) delete remote state prefix s3://%s/%s/\n3) remove %s directory\n\nContinue?",
-			m.cfg.S3Bucket, filepath.Base(m.pendingDestroyPath), m.pendingDestroyName)
-		body += tooltipStyle.Render(list)
-		// No tooltip here; options are shown in footer only to avoid duplicate boxes
+		list := fmt.Sprintf("1) terraform destroy\n2) back up, then delete remote state prefix s3://%s/%s/\n3) move %s directory to %s (kept %d days)\n\nType the deployment name to continue: %s",
+			m.cfg.S3Bucket, filepath.Base(m.pendingDestroyPath), m.pendingDestroyName, trashDir(m.cfg),
+			int(trashRetention(m.cfg).Hours()/24), m.destroyConfirmInput.View())
+		body += tooltipStyle.Render(list) + "\n"
+		body += tooltipStyle.Render(m.destroyPlanView.View()) + "\n"
+		// Options are shown in the footer; the tooltip only carries status
 		tooltip = ""
+		if m.statusMessage != "" {
+			tooltip = tooltipStyle.Render(m.statusMessage)
+		}
+	case sceneTemplateUpgrade:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Template Upgrade: " + filepath.Base(m.pendingUpgrade.DeploymentPath))
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.upgradeView.View() + "\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneLogList:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Run Logs: " + filepath.Base(m.logDeployment))
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.logTable.View() + "\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneLogView:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Run Log: " + filepath.Base(m.logRuns[m.logTable.Cursor()].Path))
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.logView.View() + "\n"
+		if m.logSearching {
+			tooltip = tooltipStyle.Render("Search: " + m.logSearch.View())
+		} else {
+			tooltip = tooltipStyle.Render(m.statusMessage)
+		}
+	case sceneOutputs:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Outputs: " + filepath.Base(m.outputsDeployment))
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.outputsTable.View() + "\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneImport:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Import VMs from " + m.importCluster)
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		tmpl := m.tfTemplates[m.tfTemplateIdx]
+		body += fmt.Sprintf(" [Template: %s] (F4/F5 to switch)  [Preset: %s] (F2/F3 to switch)  [Selected: %d VM(s)]\n",
+			tmpl.Name, m.presets[m.presetIdx].Name, len(m.importSelected))
+		body += m.importTable.View() + "\n\n"
+		for i, ti := range m.importInputs {
+			line := fmt.Sprintf("  %-25s: > %s", fieldLabel(m.fieldMeta, importLabels[i]), padRight(ti.Value(), 38))
+			if i+1 == m.importFocus {
+				body += focusedStyle.Render(line) + "\n"
+			} else {
+				body += normalStyle.Render(line) + "\n"
+			}
+		}
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneReconcile:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Reconcile: apps ↔ remote state ↔ Proxmox")
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.reconcileTable.View() + "\n"
+		for _, e := range m.reconcile.Errors {
+			body += diffWarnStyle.Render(" skipped "+e) + "\n"
+		}
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneVMs:
+		target := "selected VM"
+		if m.powerAll {
+			target = "all VMs"
+		}
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("VMs: " + m.vmDeployment.Name)
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += fmt.Sprintf(" Power actions apply to: %s (Tab to switch)\n", target)
+		body += m.vmTable.View() + "\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneSnapshots:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Snapshots: " + m.snapshotDeployment.Name)
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.snapshotTable.View() + "\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneHistory:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Tfvars History: " + filepath.Base(m.historyDeployment))
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.historyTable.View() + "\n"
+		body += tooltipStyle.Render(m.historyView.View()) + "\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneApprovals:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Approvals")
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.approvalTable.View() + "\n"
+		body += tooltipStyle.Render(m.approvalView.View()) + "\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneAudit:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Audit Log: " + auditLogPath(m.cfg))
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.auditTable.View() + "\n"
+		body += tooltipStyle.Render(m.auditView.View()) + "\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneUsage:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Usage by " + usageDimensions[m.usageDim])
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		body += m.usageTable.View() + "\n"
+		body += tooltipStyle.Render(m.usageView.View()) + "\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
+	case sceneMetadata:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Owner & Tags: " + m.metaDeployment.Name)
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		for i, f := range metaFormFields {
+			line := fmt.Sprintf("  %-25s: > %s", f.Label, padRight(m.metaInputs[i].Value(), 38))
+			if i == m.metaFocus {
+				body += focusedStyle.Render(line) + "\n"
+			} else {
+				body += normalStyle.Render(line) + "\n"
+			}
+		}
+		tooltip = tooltipStyle.Render(metaFormFields[m.metaFocus].Help + "  " + m.statusMessage)
+	case sceneRename:
+		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")).Render("Rename: " + m.renameDeployment.Name)
+		body = boxSection(centerText(title, uiWidth-4)) + "\n"
+		zone := fmt.Sprintf("  %-25s: < %s >", fieldLabel(m.fieldMeta, "zone"), m.renameZone)
+		pid := fmt.Sprintf("  %-25s: > %s", fieldLabel(m.fieldMeta, "platform_id"), m.renamePlatformID.View())
+		if m.renamePlatformID.Focused() {
+			body += normalStyle.Render(zone) + "\n" + focusedStyle.Render(pid) + "\n"
+		} else {
+			body += focusedStyle.Render(zone) + "\n" + normalStyle.Render(pid) + "\n"
+		}
+		target, err := renameTarget(m.renameDeployment, m.renameProvider,
+			renameRequest{Zone: m.renameZone, PlatformID: m.renamePlatformID.Value()})
+		if err != nil {
+			target = err.Error()
+		}
+		body += fmt.Sprintf("\n  %-25s: %s\n", "New name", target)
+		body += "\n  The directory is moved and the remote state migrated to the new key.\n"
+		tooltip = tooltipStyle.Render(m.statusMessage)
 	default:
 		body, tooltip = "", ""
 	}
@@ -368,23 +652,118 @@ func (m model) View() string {
 
 // Use this for your scene-based footer logic
 func footerForScene(m model) string {
+	if m.isBusy {
+		if m.quitWhenDone {
+			return centerText("Waiting for terraform to stop before quitting...", uiWidth)
+		}
+		return centerText("Terraform is running  │  [C] Cancel run  │  [Q] Cancel and quit", uiWidth)
+	}
 	switch m.currentScene {
 	case sceneLauncher:
-		return centerText("[↑/↓] Field  │  [N] New  │  [A] Apply  │  [U] Update  │  [D] Destroy  │  [R] Refresh  │  [Esc] Cancel", uiWidth)
+		if m.filtering {
+			return centerText("Filter: words match name, description, owner, team, env, tags, ticket; owner: team: env: tag: ticket: state: narrow │ [Enter] Keep │ [Esc] Clear", uiWidth)
+		}
+		return centerText("[↑/↓] Deployment │ [N] New │ [C] Clone │ [A] Apply │ [U] Update │ [T] Upgrade │ [M] Rename │ [D] Destroy │ [P] Protect │ [B] Usage │ [R] Refresh │ [Esc] Quit", uiWidth) + "\n" +
+			centerText("[G] Owner/tags │ [/] Filter │ [W] Approvals │ [Y] Audit log │ [V] VMs │ [S] Snapshots │ [H] History │ [L] Logs │ [O] Outputs │ [I] Import VMs │ [X] Reconcile", uiWidth)
 	case sceneCreateForm:
-		return centerText("[↑/↓] Field │ [Tab] Next │ [Enter] Save │ [Esc] Cancel", uiWidth)
+		return centerText("[↑/↓] Field │ [Tab] Next │ [F2/F3] Preset │ [F4/F5] Template │ [F6/F7] TTL │ [Enter] Save │ [Esc] Cancel", uiWidth)
 	case sceneEditForm:
-		return centerText("[↑/↓] Field │ [Tab] Next │ [Enter] Save │ [A] Apply │ [Esc] Cancel", uiWidth)
+		if m.editConfirm {
+			return centerText("[Y] Save these changes │ [N/Esc] Keep editing", uiWidth)
+		}
+		return centerText("[↑/↓] Field │ [Tab] Next │ [Ctrl+P] Plan changes │ [PgUp/PgDn] Scroll plan │ [Enter] Save │ [A] Apply │ [Esc] Cancel", uiWidth)
 	case sceneConfirmDestroy:
 		keyStyle := lipgloss.NewStyle().Bold(true)
-		opt := fmt.Sprintf("[%s] Yes │ [%s] Plan destroy │ [%s] Cancel",
-			keyStyle.Render("y"), keyStyle.Render("p"), keyStyle.Render("n/Esc"))
+		opt := fmt.Sprintf("[%s] Destroy │ [%s] Plan destroy │ [%s] Scroll plan │ [%s] Cancel",
+			keyStyle.Render("Enter"), keyStyle.Render("Ctrl+P"), keyStyle.Render("↑/↓/PgUp/PgDn"), keyStyle.Render("Esc"))
 		return centerText(opt, uiWidth)
+	case sceneTemplateUpgrade:
+		return centerText("[↑/↓/PgUp/PgDn] Scroll │ [Y] Write changes │ [Esc] Cancel", uiWidth)
+	case sceneLogList:
+		return centerText("[↑/↓] Run │ [Enter] View │ [Esc] Back", uiWidth)
+	case sceneLogView:
+		if m.logSearching {
+			return centerText("[Enter] Search │ [Esc] Cancel search", uiWidth)
+		}
+		return centerText("[↑/↓/PgUp/PgDn] Scroll │ [/] Search │ [n/N] Next/Prev match │ [Esc] Back", uiWidth)
+	case sceneOutputs:
+		return centerText("[↑/↓] Output │ [Enter/C] Copy value │ [V] Reveal sensitive │ [R] Refresh │ [Esc] Back", uiWidth)
+	case sceneImport:
+		return centerText("[Tab] Section │ [↑/↓] VM │ [Space] Select │ [←/→] Cluster/Zone │ [F2/F3] Preset │ [F4/F5] Template │ [Enter] Import │ [Esc] Cancel", uiWidth)
+	case sceneReconcile:
+		if m.reconcileConfirm {
+			return centerText("[Y] Confirm clean up │ [N/Esc] Cancel", uiWidth)
+		}
+		return centerText("[↑/↓] Orphan │ [A] Adopt │ [C] Clean up │ [R] Rescan │ [Esc] Back", uiWidth)
+	case sceneVMs:
+		if m.pendingPower != "" {
+			return centerText("[Y] Confirm "+m.pendingPower+" │ [N/Esc] Cancel", uiWidth)
+		}
+		return centerText("[↑/↓] VM │ [Tab] Selected/All │ [S] Start │ [H] Shutdown │ [B] Reboot │ [X] Stop │ [R] Refresh │ [Esc] Back", uiWidth)
+	case sceneSnapshots:
+		if m.pendingSnapshotOp != "" {
+			return centerText("[Y] Confirm "+m.pendingSnapshotOp+" │ [N/Esc] Cancel", uiWidth)
+		}
+		return centerText("[↑/↓] Snapshot │ [N] New snapshot │ [B] Roll back │ [X] Delete │ [Esc] Back", uiWidth)
+	case sceneHistory:
+		if m.pendingRevert {
+			return centerText("[Y] Confirm revert │ [N/Esc] Cancel", uiWidth)
+		}
+		return centerText("[↑/↓] Version │ [Enter] Diff vs current │ [Space] Mark to compare │ [A] Current vs applied │ [PgUp/PgDn] Scroll │ [R] Revert │ [Esc] Back", uiWidth)
+	case sceneUsage:
+		return centerText("[↑/↓] Group │ [Tab] Group by cluster/zone/app/owner/team │ [PgUp/PgDn] Scroll │ [R] Reload │ [Esc] Back", uiWidth)
+	case sceneAudit:
+		return centerText("[↑/↓] Entry │ [PgUp/PgDn] Scroll │ [E] Export CSV │ [R] Reload │ [Esc] Back", uiWidth)
+	case sceneApprovals:
+		return centerText("[↑/↓] Request │ [PgUp/PgDn] Scroll │ [A] Approve │ [R] Reject │ [G] Apply approved plan │ [Esc] Back", uiWidth)
+	case sceneMetadata:
+		return centerText("[↑/↓] Field │ [Tab] Next │ [Enter] Save │ [Esc] Cancel", uiWidth)
+	case sceneRename:
+		if m.renameConfirm {
+			return centerText("[Y] Rename and migrate state │ [N/Esc] Cancel", uiWidth)
+		}
+		return centerText("[↑/↓] Field │ [←/→] Zone │ [Enter] Rename │ [Esc] Cancel", uiWidth)
 	default:
 		return centerText("", uiWidth)
 	}
 }
 
+var (
+	diffAddStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#44cc11"))
+	diffDelStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff4444"))
+	diffHunkStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
+	diffWarnStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500")).Bold(true)
+)
+
+// colorizeDiff colors unified diff lines for display.
+func colorizeDiff(diff string) string {
+	lines := strings.Split(diff, "\n")
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"), strings.HasPrefix(l, "@@"):
+			lines[i] = diffHunkStyle.Render(l)
+		case strings.HasPrefix(l, "!!!"), strings.HasPrefix(l, "<<<<<<<"), strings.HasPrefix(l, ">>>>>>>"):
+			lines[i] = diffWarnStyle.Render(l)
+		case strings.HasPrefix(l, "+"):
+			lines[i] = diffAddStyle.Render(l)
+		case strings.HasPrefix(l, "-"):
+			lines[i] = diffDelStyle.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// colorizePlan highlights lines that warn about replacements.
+func colorizePlan(plan string) string {
+	lines := strings.Split(plan, "\n")
+	for i, l := range lines {
+		if strings.Contains(l, "⚠") {
+			lines[i] = diffWarnStyle.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func boxSection(content string) string {
 	return lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -436,6 +815,56 @@ func countLines(s string) int {
 // 	return centerText("[↑/↓] Field │ [Tab] Next │ [Enter] Save │ [A] Apply │ [Esc] Cancel", uiWidth)
 // }
 
+func deploymentColumns() []table.Column {
+	return []table.Column{
+		{Title: "Name", Width: 22},
+		{Title: "Description", Width: 14},
+		{Title: "Expires", Width: 9},
+		{Title: "State", Width: 11},
+		{Title: "Owner", Width: 8},
+		{Title: "Team", Width: 6},
+		{Title: "Env", Width: 4},
+		{Title: "Tags", Width: 6},
+		{Title: "Template", Width: 10},
+	}
+}
+
+func deploymentRows(infos []deploymentInfo) []table.Row {
+	rows := make([]table.Row, len(infos))
+	for i, info := range infos {
+		tmpl := info.Template
+		if info.TemplateOutdated {
+			tmpl = "⚠ outdated"
+		}
+		name := info.Name
+		if info.Protected {
+			name = "[P] " + name
+		}
+		rows[i] = table.Row{name, info.Description, ttlBadge(info.Expires, time.Now()), info.State, info.Owner, info.Team,
+			info.Environment, strings.Join(info.Tags, ","), tmpl}
+	}
+	return rows
+}
+
+// reloadDeployments re-reads the apps directory and refreshes both tables.
+func reloadDeployments(m model) model {
+	deployments, _ := listDeployments(m.cfg.AppsPath)
+	markOutdatedTemplates(deployments, m.tfTemplates)
+	m.allDeployments = deployments
+	return applyFilter(m)
+}
+
+// applyFilter shows the deployments matching the filter in the table.
+func applyFilter(m model) model {
+	m.deployments = filterDeployments(m.allDeployments, m.filterInput.Value())
+	m.deployTable.SetRows(deploymentRows(m.deployments))
+	if m.deployTable.Cursor() >= len(m.deployments) {
+		m.deployTable.SetCursor(max(0, len(m.deployments)-1))
+	}
+	m.tfvarsTable = loadTfvarsTableForDeployment(m.cfg.AppsPath, m.deployments, m.deployTable.Cursor(), m.fieldMeta)
+	return m
+}
+
 // Loads tfvars for the selected deployment index, from real data
 func loadTfvarsTableForDeployment(appsPath string, infos []deploymentInfo, idx int, fieldMeta map[string]FieldMeta) table.Model {
 	tfvarsCols := []table.Column{
@@ -462,8 +891,45 @@ func loadTfvarsTableForDeployment(appsPath string, infos []deploymentInfo, idx i
 	return tfvarsTable
 }
 
+// vmStatusSection returns the VM lines of the details panel for deployment idx.
+func (m model) vmStatusSection(idx int) []string {
+	if idx < 0 || idx >= len(m.deployments) {
+		return nil
+	}
+	path := m.deployments[idx].Path
+	if e, ok := m.vmStatusErrs[path]; ok {
+		return []string{"status unavailable: " + e}
+	}
+	statuses, ok := m.vmStatuses[path]
+	if !ok {
+		return []string{"loading..."}
+	}
+	if len(statuses) == 0 {
+		return []string{"no VMs found"}
+	}
+	metrics := make(map[int]VMMetrics)
+	for _, vm := range m.vmMetrics[path] {
+		metrics[vm.VmID] = vm
+	}
+	var lines []string
+	for _, st := range statuses {
+		vm, ok := metrics[st.VmID]
+		if !ok {
+			lines = append(lines, vmStatusLine(st))
+			continue
+		}
+		if vm.Hint != "" {
+			lines = append(lines, diffWarnStyle.Render(vmStatusLine(st)), diffWarnStyle.Render("  ⚠ "+vm.Hint))
+		} else {
+			lines = append(lines, vmStatusLine(st))
+		}
+		lines = append(lines, metricsLines(vm)...)
+	}
+	return lines
+}
+
 // renderDetailsPanel formats the selected deployment's tfvars as non-scrollable text.
-func renderDetailsPanel(appsPath string, infos []deploymentInfo, idx int, fieldMeta map[string]FieldMeta, width int, maxHeight int) string {
+func renderDetailsPanel(appsPath string, infos []deploymentInfo, idx int, fieldMeta map[string]FieldMeta, vmLines []string, width int, maxHeight int) string {
 	if idx < 0 || idx >= len(infos) {
 		return strings.Repeat(" ", width)
 	}
@@ -479,34 +945,161 @@ func renderDetailsPanel(appsPath string, infos []deploymentInfo, idx int, fieldM
 		rows = append(rows, kv{k: k, v: v, label: label})
 	}
 	sort.Slice(rows, func(i, j int) bool { return rows[i].label < rows[j].label })
-	var b strings.Builder
-	// Header
-	b.WriteString(padRight("Details", width))
-	b.WriteString("\n")
-	count := 0
+	lines := []string{"Details"}
 	for _, r := range rows {
-		line := fmt.Sprintf("%-28s %s", r.label+":", r.v)
-		if len(line) > width {
-			line = line[:width]
+		lines = append(lines, fmt.Sprintf("%-28s %s", r.label+":", r.v))
+	}
+	dep := infos[idx]
+	for _, kv := range [][2]string{
+		{"Last action:", dep.LastAction},
+		{"Owner:", dep.Owner},
+		{"Team:", dep.Team},
+		{"Environment:", dep.Environment},
+		{"Tags:", strings.Join(dep.Tags, ", ")},
+		{"Change ticket:", dep.Ticket},
+		{"Approval:", dep.Approval},
+		{"Expires:", strings.TrimSpace(dep.Expires + " " + ttlBadge(dep.Expires, time.Now()))},
+	} {
+		if kv[1] != "" {
+			lines = append(lines, fmt.Sprintf("%-28s %s", kv[0], kv[1]))
 		}
-		b.WriteString(padRight(line, width))
-		b.WriteString("\n")
-		count++
-		if count >= maxHeight-2 { // leave room for header spacing
+	}
+	if meta, _ := readDeploymentMeta(dep.Path); meta.ClonedFrom != "" {
+		lines = append(lines, fmt.Sprintf("%-28s %s", "Cloned from:", meta.ClonedFrom))
+	}
+	if len(vmLines) > 0 {
+		lines = append(lines, "", "VMs")
+		lines = append(lines, vmLines...)
+	}
+	if snaps, _ := listSnapshots(infos[idx].Path); len(snaps) > 0 {
+		lines = append(lines, "", "Snapshots")
+		for i, s := range snaps {
+			if i == 3 {
+				lines = append(lines, fmt.Sprintf("... %d more ([S] to list)", len(snaps)-3))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("%-28s %s", s.Name, s.CreatedAt))
+		}
+	}
+	if cache, err := loadCachedOutputs(infos[idx].Path); err == nil && len(cache.Outputs) > 0 {
+		lines = append(lines, "", "Outputs")
+		for _, name := range sortedKeys(cache.Outputs) {
+			value := formatOutputValue(cache.Outputs[name].Value)
+			if cache.Outputs[name].Sensitive {
+				value = "(sensitive)"
+			}
+			lines = append(lines, fmt.Sprintf("%-28s %s", name+":", value))
+		}
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		if i >= maxHeight-1 { // leave room for header spacing
 			break
 		}
+		line = ansi.Truncate(line, width, "")
+		b.WriteString(line + strings.Repeat(" ", width-ansi.StringWidth(line)))
+		b.WriteString("\n")
 	}
 	return b.String()
 }
 
 // --- Update logic: only allow quit during isBusy
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Status updates arrive in the background and only refresh caches
+	if msg, ok := msg.(vmStatusMsg); ok {
+		if msg.err != nil {
+			m.vmStatusErrs[msg.path] = msg.err.Error()
+		} else {
+			delete(m.vmStatusErrs, msg.path)
+			m.vmStatuses[msg.path] = msg.statuses
+		}
+		if m.currentScene == sceneVMs && msg.path == m.vmDeployment.Path {
+			m.vmTable.SetRows(vmRows(m.vmStatuses[msg.path]))
+			if msg.err != nil {
+				m.statusMessage = "Could not read VM status: " + msg.err.Error()
+			}
+		}
+		return m, nil
+	}
+	if msg, ok := msg.(vmMetricsMsg); ok {
+		if msg.err == nil {
+			m.vmMetrics[msg.path] = msg.metrics
+		}
+		return m, nil
+	}
+	if _, ok := msg.(metricsTickMsg); ok {
+		// Only the launcher shows metrics; other scenes just keep the timer running
+		tick := metricsTickCmd(metricsInterval(m.cfg))
+		idx := m.deployTable.Cursor()
+		if m.currentScene != sceneLauncher || idx < 0 || idx >= len(m.deployments) {
+			return m, tick
+		}
+		dep := m.deployments[idx]
+		return m, tea.Batch(fetchVMStatusCmd(dep), fetchVMMetricsCmd(dep), tick)
+	}
+	if msg, ok := msg.(snapshotOpMsg); ok {
+		m.snapshotRunning = false
+		m.statusMessage = msg.result
+		if msg.err != nil {
+			m.statusMessage = msg.err.Error()
+		}
+		m = reloadDeployments(m)
+		if m.currentScene == sceneSnapshots {
+			m = openSnapshots(m)
+		}
+		return m, nil
+	}
+	if msg, ok := msg.(outputValueMsg); ok {
+		switch {
+		case msg.err != nil:
+			m.statusMessage = msg.err.Error()
+		case msg.copy:
+			if err := copyToClipboard(msg.value); err != nil {
+				m.statusMessage = "Copy failed: " + err.Error()
+			} else {
+				m.statusMessage = fmt.Sprintf("Copied '%s' to the clipboard.", msg.name)
+			}
+		case m.currentScene == sceneOutputs && msg.dir == m.outputsDeployment:
+			m.outputsRevealed[msg.name] = msg.value
+			m = showOutputRows(m)
+			m.statusMessage = fmt.Sprintf("'%s' revealed until you leave the outputs.", msg.name)
+		}
+		return m, nil
+	}
+	if msg, ok := msg.(powerDoneMsg); ok {
+		m.powerRunning = false
+		if msg.err != nil {
+			m.statusMessage = msg.err.Error()
+		} else {
+			m.statusMessage = msg.result
+		}
+		return m, fetchVMStatusCmd(m.vmDeployment)
+	}
 	if m.isBusy {
 		switch msg := msg.(type) {
+		case terraformDoneMsg:
+			m.isBusy = false
+			m.cancelRun = nil
+			if m.quitWhenDone {
+				next, _ := handleTerraformDone(m, msg)
+				return next, tea.Quit
+			}
+			return handleTerraformDone(m, msg)
 		case tea.KeyMsg:
 			switch msg.String() {
+			case "c", "ctrl+c":
+				// Interrupt terraform and wait for it to release the state lock
+				m.cancelRun()
+				m.statusMessage = "Cancelling terraform, waiting for it to stop and release the state lock..."
+				m.editStatus = m.statusMessage
+				return m, nil
 			case "q", "esc":
-				return m, tea.Quit
+				// Stop the running terraform process and quit once it released the state lock
+				m.cancelRun()
+				m.quitWhenDone = true
+				m.statusMessage = "Cancelling terraform, quitting once it has stopped and released the state lock..."
+				m.editStatus = m.statusMessage
+				return m, nil
 			default:
 				return m, nil
 			}
@@ -523,23 +1116,100 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return updateEditForm(m, msg)
 	case sceneConfirmDestroy:
 		return updateConfirmDestroy(m, msg)
+	case sceneTemplateUpgrade:
+		return updateTemplateUpgrade(m, msg)
+	case sceneLogList:
+		return updateLogList(m, msg)
+	case sceneLogView:
+		return updateLogView(m, msg)
+	case sceneOutputs:
+		return updateOutputs(m, msg)
+	case sceneImport:
+		return updateImport(m, msg)
+	case sceneReconcile:
+		return updateReconcile(m, msg)
+	case sceneVMs:
+		return updateVMs(m, msg)
+	case sceneSnapshots:
+		return updateSnapshots(m, msg)
+	case sceneHistory:
+		return updateHistory(m, msg)
+	case sceneRename:
+		return updateRename(m, msg)
+	case sceneMetadata:
+		return updateMetadata(m, msg)
+	case sceneApprovals:
+		return updateApprovals(m, msg)
+	case sceneAudit:
+		return updateAudit(m, msg)
+	case sceneUsage:
+		return updateUsage(m, msg)
 	}
 	return m, nil
 }
 
 func updateLauncher(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.filtering {
+		switch keyMsg.String() {
+		case "enter":
+			m.filtering = false
+			m.filterInput.Blur()
+			return m, nil
+		case "esc":
+			m.filtering = false
+			m.filterInput.Blur()
+			m.filterInput.SetValue("")
+			return applyFilter(m), nil
+		}
+		var cmd tea.Cmd
+		m.filterInput, cmd = m.filterInput.Update(msg)
+		return applyFilter(m), cmd
+	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "/":
+			m.filtering = true
+			m.filterInput.Focus()
+			return m, textinput.Blink
+		case "w":
+			return openApprovals(m), nil
+		case "y":
+			return openAudit(m), nil
+		case "b":
+			return openUsage(m), nil
+		case "g":
+			idx := m.deployTable.Cursor()
+			if idx >= 0 && idx < len(m.deployments) {
+				return openMetadata(m, m.deployments[idx]), nil
+			}
 		case "up", "k", "down", "j":
 			var cmd tea.Cmd
 			m.deployTable, cmd = m.deployTable.Update(msg)
 			selected := m.deployTable.Cursor()
 			m.tfvarsTable = loadTfvarsTableForDeployment(m.cfg.AppsPath, m.deployments, selected, m.fieldMeta)
+			if selected >= 0 && selected < len(m.deployments) {
+				if _, ok := m.vmStatuses[m.deployments[selected].Path]; !ok {
+					dep := m.deployments[selected]
+					return m, tea.Batch(cmd, fetchVMStatusCmd(dep), fetchVMMetricsCmd(dep))
+				}
+			}
 			return m, cmd
 		case "n":
+			m.cloneSource = ""
+			m.statusMessage = ""
 			m.currentScene = sceneCreateForm
 			return m, nil
+		case "c":
+			idx := m.deployTable.Cursor()
+			if idx >= 0 && idx < len(m.deployments) {
+				return openClone(m, m.deployments[idx])
+			}
+		case "m":
+			idx := m.deployTable.Cursor()
+			if idx >= 0 && idx < len(m.deployments) {
+				return openRename(m, m.deployments[idx]), nil
+			}
 		case "enter", "e":
 			idx := m.deployTable.Cursor()
 			if idx >= 0 && idx < len(m.deployments) {
@@ -550,13 +1220,31 @@ func updateLauncher(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.editStatus = "Could not load tfvars: " + err.Error()
 					return m, nil
 				}
-				// Build edit form with only editable fields
-				inputs, labels := buildEditFormInputs(vals, m.fieldMeta, m.createLabels)
+				// Build edit form with only editable fields of the deployment's template
+				fields := defaultTemplateFields
+				if meta, err := readDeploymentMeta(dep.Path); err == nil {
+					if i := findTemplate(m.tfTemplates, meta.Template); i >= 0 {
+						fields = m.tfTemplates[i].Fields
+					}
+				}
+				inputs, labels := buildEditFormInputs(vals, m.fieldMeta, fields)
+				if len(inputs) == 0 {
+					m.statusMessage = "No editable fields for " + dep.Name
+					return m, nil
+				}
 				inputs[0].Focus()
 				m.editFormInputs = inputs
 				m.editFormLabels = labels
 				m.editFormPath = tfvars
 				m.editFocusIndex = 0
+				m.editOriginal = make([]string, len(inputs))
+				for i := range inputs {
+					m.editOriginal[i] = inputs[i].Value()
+				}
+				m.editConfirm = false
+				m.editStatus = ""
+				m.editPlan, m.editPlanFor = nil, ""
+				m.editPlanView = viewport.New(uiWidth-8, 10)
 				m.currentScene = sceneEditForm
 				return m, nil
 			}
@@ -564,109 +1252,1729 @@ func updateLauncher(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			idx := m.deployTable.Cursor()
 			if idx >= 0 && idx < len(m.deployments) {
 				dep := m.deployments[idx]
-				m.pendingDestroyIdx = idx
+				if reason := protectionReason(m.cfg, dep); reason != "" {
+					m.statusMessage = "Destroy blocked: " + reason + "."
+					return m, nil
+				}
 				m.pendingDestroyName = dep.Name
 				m.pendingDestroyPath = dep.Path
+				m.destroyConfirmInput = textinput.New()
+				m.destroyConfirmInput.Placeholder = dep.Name
+				m.destroyConfirmInput.Focus()
+				m.destroyPlanView = viewport.New(uiWidth-4, 14)
+				m.destroyPlanView.SetContent("Press Ctrl+P to preview what will be destroyed.")
+				m.statusMessage = ""
 				m.currentScene = sceneConfirmDestroy
 				return m, nil
 			}
+		case "P":
+			idx := m.deployTable.Cursor()
+			if idx >= 0 && idx < len(m.deployments) {
+				dep := m.deployments[idx]
+				if err := setDeploymentProtected(dep.Path, !dep.Protected); err != nil {
+					m.statusMessage = "Could not update protection: " + err.Error()
+					return m, nil
+				}
+				action := "protect"
+				if dep.Protected {
+					action = "unprotect"
+					m.statusMessage = fmt.Sprintf("'%s' is no longer protected.", dep.Name)
+				} else {
+					m.statusMessage = fmt.Sprintf("'%s' is now protected.", dep.Name)
+				}
+				m.statusMessage += auditOutcome(m.cfg, action, dep.Name, "", nil)
+				m = reloadDeployments(m)
+				m.deployTable.SetCursor(idx)
+			}
+			return m, nil
+		case "t", "T":
+			idx := m.deployTable.Cursor()
+			if idx < 0 || idx >= len(m.deployments) {
+				return m, nil
+			}
+			dep := m.deployments[idx]
+			ti := findTemplate(m.tfTemplates, dep.Template)
+			if ti < 0 {
+				m.statusMessage = fmt.Sprintf("Template of '%s' is unknown; cannot upgrade.", dep.Name)
+				return m, nil
+			}
+			upgrade, err := planTemplateUpgrade(m.cfg, m.tfTemplates[ti], dep.Path)
+			if err != nil {
+				m.statusMessage = "Template upgrade failed: " + err.Error()
+				return m, nil
+			}
+			if upgrade.FromVersion == upgrade.ToVersion {
+				m.statusMessage = fmt.Sprintf("'%s' is already on the latest template version.", dep.Name)
+				return m, nil
+			}
+			m.pendingUpgrade = upgrade
+			m.upgradeView = viewport.New(uiWidth-4, 26)
+			m.upgradeView.SetContent(colorizeDiff(upgrade.Diff()))
+			m.statusMessage = fmt.Sprintf("%d file(s) changed, %d conflict(s).", len(upgrade.Changes), upgrade.Conflicts())
+			m.currentScene = sceneTemplateUpgrade
+			return m, nil
+		case "l", "L":
+			idx := m.deployTable.Cursor()
+			if idx >= 0 && idx < len(m.deployments) {
+				m.logDeployment = m.deployments[idx].Path
+				return openLogList(m), nil
+			}
+		case "o", "O":
+			idx := m.deployTable.Cursor()
+			if idx >= 0 && idx < len(m.deployments) {
+				m.outputsDeployment = m.deployments[idx].Path
+				m.statusMessage = ""
+				return openOutputs(m), nil
+			}
+		case "i", "I":
+			return openImport(m, clusterOptions[0])
+		case "x", "X":
+			return openReconcile(m)
+		case "v", "V":
+			idx := m.deployTable.Cursor()
+			if idx >= 0 && idx < len(m.deployments) {
+				return openVMs(m, m.deployments[idx])
+			}
+		case "h", "H":
+			idx := m.deployTable.Cursor()
+			if idx >= 0 && idx < len(m.deployments) {
+				m.historyDeployment = m.deployments[idx].Path
+				m.statusMessage = ""
+				return openHistory(m), nil
+			}
+		case "s", "S":
+			idx := m.deployTable.Cursor()
+			if idx >= 0 && idx < len(m.deployments) {
+				m.snapshotDeployment = m.deployments[idx]
+				m.statusMessage = ""
+				return openSnapshots(m), nil
+			}
 		case "q", "esc":
 			return m, tea.Quit
 		case "r", "R":
 			m.statusMessage = "Refreshing deployments..."
-			deployments, _ := listDeployments(m.cfg.AppsPath)
-			m.deployments = deployments
-			// Refresh deployTable and tfvarsTable as needed
-			deployRows := make([]table.Row, len(deployments))
-			for i, info := range deployments {
-				deployRows[i] = table.Row{info.Name, info.Description, info.State, info.LastAction}
-			}
-			m.deployTable.SetRows(deployRows)
-			m.tfvarsTable = loadTfvarsTableForDeployment(m.cfg.AppsPath, deployments, 0, m.fieldMeta)
+			m = reloadDeployments(m)
+			m.vmStatuses = make(map[string][]VMStatus)
+			m.vmMetrics = make(map[string][]VMMetrics)
+			var cmd tea.Cmd
+			if idx := m.deployTable.Cursor(); idx >= 0 && idx < len(m.deployments) {
+				cmd = tea.Batch(fetchVMStatusCmd(m.deployments[idx]), fetchVMMetricsCmd(m.deployments[idx]))
+			}
 			// Refresh status bars in-place
 			updateStatusBars(&m)
 			m.statusMessage = "Deployments refreshed!"
-			return m, nil
+			if n, err := purgeTrash(m.cfg); err != nil {
+				m.statusMessage += " Trash cleanup failed: " + err.Error()
+			} else if n > 0 {
+				m.statusMessage += fmt.Sprintf(" Purged %d expired trash entr(ies).", n)
+			}
+			return m, cmd
 
 		}
 	}
 	return m, nil
 }
 
+// pendingDestroyDeployment looks up the deployment being destroyed by its
+// path, since the table may have been reloaded while the name was typed.
+func pendingDestroyDeployment(m model) (deploymentInfo, bool) {
+	for _, dep := range m.deployments {
+		if dep.Path == m.pendingDestroyPath {
+			return dep, dep.Name == m.pendingDestroyName
+		}
+	}
+	return deploymentInfo{}, false
+}
+
 func updateConfirmDestroy(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "y", "enter":
-			// Proceed with destroy
-			if m.pendingDestroyIdx >= 0 && m.pendingDestroyIdx < len(m.deployments) {
-				dep := m.deployments[m.pendingDestroyIdx]
-				m.statusMessage = "Running terraform destroy..."
-				if err := runTerraformDestroy(dep.Path); err != nil {
-					m.statusMessage = "terraform destroy failed: " + err.Error()
-					m.currentScene = sceneLauncher
-					return m, nil
-				}
-				_ = setDeploymentState(dep.Path, "DESTROYED", "destroy")
-				// Remove S3 prefix (best-effort)
-				appDir := filepath.Base(dep.Path)
-				_ = deleteS3Prefix(m.cfg.S3Bucket, fmt.Sprintf("%s/", appDir), m.cfg.AWSProfile, m.cfg.AWSRegion)
-				// Remove local directory (best-effort)
-				if err := os.RemoveAll(dep.Path); err != nil {
-					m.statusMessage = "Destroyed, but failed to delete directory: " + err.Error()
-				} else {
-					m.statusMessage = "Destroyed: terraform + remote state + directory removed."
-				}
-				// Refresh
-				deployments, _ := listDeployments(m.cfg.AppsPath)
-				m.deployments = deployments
-				deployRows := make([]table.Row, len(deployments))
-				for i, info := range deployments {
-					deployRows[i] = table.Row{info.Name, info.Description, info.State, info.LastAction}
-				}
-				m.deployTable.SetRows(deployRows)
-				m.tfvarsTable = loadTfvarsTableForDeployment(m.cfg.AppsPath, deployments, 0, m.fieldMeta)
+		case "enter":
+			// Proceed with destroy only once the deployment name was typed
+			if m.destroyConfirmInput.Value() != m.pendingDestroyName {
+				m.statusMessage = fmt.Sprintf("Type '%s' exactly to confirm the destroy.", m.pendingDestroyName)
+				return m, nil
 			}
-			m.currentScene = sceneLauncher
-			return m, nil
-		case "p":
+			dep, ok := pendingDestroyDeployment(m)
+			if !ok {
+				m.statusMessage = fmt.Sprintf("'%s' is no longer in the apps directory; destroy aborted.", m.pendingDestroyName)
+				m.currentScene = sceneLauncher
+				return m, nil
+			}
+			tf, cfg := m.tf, m.cfg
+			return m.withScene(sceneLauncher).startTerraform("destroy", dep.Path, "Running terraform destroy...",
+				func(ctx context.Context) terraformDoneMsg {
+					result, err := destroyDeployment(ctx, tf, cfg, dep)
+					return terraformDoneMsg{result: result, err: err}
+				})
+		case "ctrl+p":
 			// Dry-run plan
-			if m.pendingDestroyIdx >= 0 && m.pendingDestroyIdx < len(m.deployments) {
-				dep := m.deployments[m.pendingDestroyIdx]
-				if err := runTerraformPlanDestroy(dep.Path); err != nil {
-					m.statusMessage = "plan -destroy failed: " + err.Error()
-				} else {
-					m.statusMessage = "plan -destroy completed successfully."
-				}
+			if dep, ok := pendingDestroyDeployment(m); ok {
+				tf := m.tf
+				// Keep user in confirm view after plan
+				return m.startTerraform("plan-destroy", dep.Path, "Running terraform plan -destroy...",
+					func(ctx context.Context) terraformDoneMsg {
+						plan, err := runTerraformPlanDestroy(ctx, tf, dep.Path)
+						return terraformDoneMsg{plan: plan, err: err}
+					})
 			}
-			// Keep user in confirm view after plan
 			return m, nil
-		case "n", "esc":
+		case "up", "down", "pgup", "pgdown":
+			var cmd tea.Cmd
+			m.destroyPlanView, cmd = m.destroyPlanView.Update(msg)
+			return m, cmd
+		case "esc":
 			// Cancel destroy
 			m.statusMessage = "Destroy canceled."
 			m.currentScene = sceneLauncher
 			return m, nil
 		}
 	}
-	return m, nil
+	var cmd tea.Cmd
+	m.destroyConfirmInput, cmd = m.destroyConfirmInput.Update(msg)
+	return m, cmd
 }
 
-func (m model) withScene(s scene) model {
-	m.currentScene = s
-	return m
+func updateTemplateUpgrade(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "y", "Y":
+			name := filepath.Base(m.pendingUpgrade.DeploymentPath)
+			if err := applyTemplateUpgrade(m.pendingUpgrade); err != nil {
+				m.statusMessage = "Template upgrade not written: " + err.Error() + auditOutcome(m.cfg, "upgrade", name, "", err)
+				return m, nil
+			}
+			m.statusMessage = fmt.Sprintf("Template upgraded for '%s'. Review and apply the deployment.", name)
+			m.statusMessage += auditOutcome(m.cfg, "upgrade", name, "", nil)
+			m.pendingUpgrade = nil
+			m = reloadDeployments(m)
+			return m.withScene(sceneLauncher), nil
+		case "n", "esc", "q":
+			m.pendingUpgrade = nil
+			m.statusMessage = "Template upgrade canceled."
+			return m.withScene(sceneLauncher), nil
+		}
+	}
+	var cmd tea.Cmd
+	m.upgradeView, cmd = m.upgradeView.Update(msg)
+	return m, cmd
 }
 
-func buildEditFormInputs(tfvars map[string]string, fieldMeta map[string]FieldMeta, orderedFields []string) ([]textinput.Model, []string) {
-	var labels []string
-	for _, key := range orderedFields {
-		if !fieldMeta[key].ReadOnly {
-			labels = append(labels, key)
-		}
+// outputValueMsg carries a fetched sensitive output, to show or to copy.
+type outputValueMsg struct {
+	dir, name, value string
+	copy             bool
+	err              error
+}
+
+func fetchOutputValueCmd(tf TerraformRunner, dir, name string, copy bool) tea.Cmd {
+	return func() tea.Msg {
+		value, err := fetchOutputValue(context.Background(), tf, dir, name)
+		return outputValueMsg{dir: dir, name: name, value: value, copy: copy, err: err}
 	}
-	inputs := make([]textinput.Model, len(labels))
-	for i, key := range labels {
-		ti := textinput.New()
-		ti.Placeholder = key
+}
+
+// openOutputs shows the cached terraform outputs of m.outputsDeployment.
+func openOutputs(m model) model {
+	cache, err := loadCachedOutputs(m.outputsDeployment)
+	if err != nil && !os.IsNotExist(err) {
+		m.statusMessage = "Could not read cached outputs: " + err.Error()
+	}
+	m.outputsCache = cache
+	m.outputsRevealed = make(map[string]string)
+	m.outputNames = sortedKeys(cache.Outputs)
+	m.outputsTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Output", Width: 32},
+			{Title: "Value", Width: uiWidth - 40},
+		}),
+		table.WithFocused(true),
+	)
+	m = showOutputRows(m)
+	m.outputsTable.SetHeight(24)
+	if m.statusMessage == "" {
+		if cache.FetchedAt == "" {
+			m.statusMessage = "No cached outputs; press R to run terraform output."
+		} else {
+			m.statusMessage = "Outputs fetched " + cache.FetchedAt
+		}
+	}
+	return m.withScene(sceneOutputs)
+}
+
+// showOutputRows fills the outputs table, hiding sensitive values that weren't revealed.
+func showOutputRows(m model) model {
+	rows := make([]table.Row, len(m.outputNames))
+	for i, name := range m.outputNames {
+		value := formatOutputValue(m.outputsCache.Outputs[name].Value)
+		if m.outputsCache.Outputs[name].Sensitive {
+			value = "(sensitive; [V] to reveal)"
+			if v, ok := m.outputsRevealed[name]; ok {
+				value = v
+			}
+		}
+		rows[i] = table.Row{name, value}
+	}
+	m.outputsTable.SetRows(rows)
+	return m
+}
+
+func updateOutputs(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc", "q":
+			m.statusMessage = ""
+			return m.withScene(sceneLauncher), nil
+		case "r", "R":
+			tf, dir := m.tf, m.outputsDeployment
+			return m.startTerraform("output", dir, "Running terraform output...",
+				func(ctx context.Context) terraformDoneMsg {
+					_, err := runTerraformOutput(ctx, tf, dir)
+					return terraformDoneMsg{err: err}
+				})
+		case "v", "V":
+			idx := m.outputsTable.Cursor()
+			if idx < 0 || idx >= len(m.outputNames) || !m.outputsCache.Outputs[m.outputNames[idx]].Sensitive {
+				return m, nil
+			}
+			if _, ok := m.outputsRevealed[m.outputNames[idx]]; ok {
+				delete(m.outputsRevealed, m.outputNames[idx])
+				return showOutputRows(m), nil
+			}
+			m.statusMessage = "Reading " + m.outputNames[idx] + " from terraform..."
+			return m, fetchOutputValueCmd(m.tf, m.outputsDeployment, m.outputNames[idx], false)
+		case "enter", "c", "C":
+			idx := m.outputsTable.Cursor()
+			if idx < 0 || idx >= len(m.outputNames) {
+				return m, nil
+			}
+			name := m.outputNames[idx]
+			value := formatOutputValue(m.outputsCache.Outputs[name].Value)
+			if m.outputsCache.Outputs[name].Sensitive {
+				v, ok := m.outputsRevealed[name]
+				if !ok {
+					m.statusMessage = "Reading " + name + " from terraform..."
+					return m, fetchOutputValueCmd(m.tf, m.outputsDeployment, name, true)
+				}
+				value = v
+			}
+			if err := copyToClipboard(value); err != nil {
+				m.statusMessage = "Copy failed: " + err.Error()
+			} else {
+				m.statusMessage = fmt.Sprintf("Copied '%s' to the clipboard.", name)
+			}
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.outputsTable, cmd = m.outputsTable.Update(msg)
+	return m, cmd
+}
+
+// importLabels are the form fields of the import scene; the rest of the
+// tfvars come from the preset and the VM config.
+var importLabels = []string{"vm_app", "zone", "platform_id"}
+
+// vmsFetchedMsg carries the VMs of a cluster for the import scene.
+type vmsFetchedMsg struct {
+	cluster string
+	vms     []ProxmoxVM
+	err     error
+}
+
+func fetchVMsCmd(cluster string) tea.Cmd {
+	return func() tea.Msg {
+		vms, err := listProxmoxVMs(cluster)
+		return vmsFetchedMsg{cluster, vms, err}
+	}
+}
+
+// openImport resets the import scene to cluster and starts fetching its VMs.
+func openImport(m model, cluster string) (model, tea.Cmd) {
+	m.importCluster = cluster
+	m.importVMs = nil
+	m.importSelected = make(map[int]bool)
+	m.importTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: " ", Width: 3},
+			{Title: "VMID", Width: 8},
+			{Title: "Name", Width: 40},
+			{Title: "Node", Width: 16},
+			{Title: "Status", Width: 10},
+		}),
+		table.WithFocused(true),
+	)
+	m.importTable.SetHeight(16)
+	if len(m.importInputs) == 0 {
+		m.importInputs = newCreateInputs(importLabels)
+		m.importInputs[0].Blur()
+		m.importInputs[1].SetValue(zoneOptions[0])
+	}
+	m.importFocus = 0
+	m.statusMessage = fmt.Sprintf("Fetching VMs of %s...", cluster)
+	return m.withScene(sceneImport), fetchVMsCmd(cluster)
+}
+
+func importRows(vms []ProxmoxVM, selected map[int]bool) []table.Row {
+	rows := make([]table.Row, len(vms))
+	for i, vm := range vms {
+		mark := ""
+		if selected[vm.VmID] {
+			mark = "[x]"
+		}
+		rows[i] = table.Row{mark, fmt.Sprintf("%d", vm.VmID), vm.Name, vm.Node, vm.Status}
+	}
+	return rows
+}
+
+func updateImport(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case vmsFetchedMsg:
+		if msg.cluster != m.importCluster {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = "Could not list VMs: " + msg.err.Error()
+			return m, nil
+		}
+		m.importVMs = msg.vms
+		m.importTable.SetRows(importRows(m.importVMs, m.importSelected))
+		m.statusMessage = fmt.Sprintf("%d VM(s) on %s. Select the VMs that make up one deployment.", len(msg.vms), msg.cluster)
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.statusMessage = "Import canceled."
+			return m.withScene(sceneLauncher), nil
+		case "tab", "shift+tab":
+			dir := 1
+			if msg.String() == "shift+tab" {
+				dir = -1
+			}
+			m.importFocus = (m.importFocus + dir + len(m.importInputs) + 1) % (len(m.importInputs) + 1)
+			for i := range m.importInputs {
+				if i+1 == m.importFocus {
+					m.importInputs[i].Focus()
+				} else {
+					m.importInputs[i].Blur()
+				}
+			}
+			if m.importFocus == 0 {
+				m.importTable.Focus()
+			} else {
+				m.importTable.Blur()
+			}
+			return m, nil
+		case "f2":
+			m.presetIdx = (m.presetIdx - 1 + len(m.presets)) % len(m.presets)
+			if i := findTemplate(m.tfTemplates, m.presets[m.presetIdx].Template); i >= 0 {
+				m.tfTemplateIdx = i
+			}
+			return m, nil
+		case "f3":
+			m.presetIdx = (m.presetIdx + 1) % len(m.presets)
+			if i := findTemplate(m.tfTemplates, m.presets[m.presetIdx].Template); i >= 0 {
+				m.tfTemplateIdx = i
+			}
+			return m, nil
+		case "f4":
+			m.tfTemplateIdx = (m.tfTemplateIdx - 1 + len(m.tfTemplates)) % len(m.tfTemplates)
+			return m, nil
+		case "f5":
+			m.tfTemplateIdx = (m.tfTemplateIdx + 1) % len(m.tfTemplates)
+			return m, nil
+		case "enter":
+			return startImport(m)
+		}
+		if m.importFocus == 0 {
+			switch msg.String() {
+			case " ":
+				idx := m.importTable.Cursor()
+				if idx >= 0 && idx < len(m.importVMs) {
+					id := m.importVMs[idx].VmID
+					if m.importSelected[id] {
+						delete(m.importSelected, id)
+					} else {
+						m.importSelected[id] = true
+					}
+					m.importTable.SetRows(importRows(m.importVMs, m.importSelected))
+				}
+				return m, nil
+			case "left":
+				return openImport(m, cycleOption(m.importCluster, clusterOptions, -1))
+			case "right":
+				return openImport(m, cycleOption(m.importCluster, clusterOptions, +1))
+			}
+			var cmd tea.Cmd
+			m.importTable, cmd = m.importTable.Update(msg)
+			return m, cmd
+		}
+		if importLabels[m.importFocus-1] == "zone" {
+			switch msg.String() {
+			case "left":
+				m.importInputs[m.importFocus-1].SetValue(cycleOption(m.importInputs[m.importFocus-1].Value(), zoneOptions, -1))
+			case "right", " ":
+				m.importInputs[m.importFocus-1].SetValue(cycleOption(m.importInputs[m.importFocus-1].Value(), zoneOptions, +1))
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.importInputs[m.importFocus-1], cmd = m.importInputs[m.importFocus-1].Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// startImport validates the import form and runs the import in the background.
+func startImport(m model) (tea.Model, tea.Cmd) {
+	req := importRequest{
+		Template: m.tfTemplates[m.tfTemplateIdx],
+		Preset:   m.presets[m.presetIdx],
+		Cluster:  m.importCluster,
+		Values:   make(map[string]string),
+	}
+	for _, vm := range m.importVMs {
+		if m.importSelected[vm.VmID] {
+			req.VMs = append(req.VMs, vm)
+		}
+	}
+	for i, key := range importLabels {
+		req.Values[key] = strings.TrimSpace(m.importInputs[i].Value())
+		if req.Values[key] == "" {
+			m.statusMessage = fmt.Sprintf("%s is required.", fieldLabel(m.fieldMeta, key))
+			return m, nil
+		}
+	}
+	if len(req.VMs) == 0 {
+		m.statusMessage = "Select at least one VM with Space."
+		return m, nil
+	}
+	req.AppDir = fmt.Sprintf("%s_%s_%s_%s", req.Template.Provider, req.Values["vm_app"], req.Values["zone"], req.Values["platform_id"])
+	destPath := filepath.Join(m.cfg.AppsPath, req.AppDir)
+	if _, err := os.Stat(destPath); err == nil {
+		m.statusMessage = fmt.Sprintf("Deployment '%s' already exists!", req.AppDir)
+		return m, nil
+	}
+	tf, cfg := m.tf, m.cfg
+	return m.withScene(sceneLauncher).startTerraform("import", destPath,
+		fmt.Sprintf("Importing %d VM(s) into '%s'...", len(req.VMs), req.AppDir),
+		func(ctx context.Context) terraformDoneMsg {
+			if _, err := prepareImport(cfg, req); err != nil {
+				return terraformDoneMsg{err: err}
+			}
+			result, err := runImport(ctx, tf, destPath, req)
+			return terraformDoneMsg{result: result, err: err}
+		})
+}
+
+// reconcileMsg carries a finished reconciliation scan.
+type reconcileMsg struct {
+	report reconcileReport
+	err    error
+}
+
+// orphanActionMsg reports a clean up or adopt action on an orphan.
+type orphanActionMsg struct {
+	result string
+	err    error
+}
+
+func reconcileCmd(cfg Config, clusters []string) tea.Cmd {
+	return func() tea.Msg {
+		report, err := reconcile(cfg, clusters)
+		return reconcileMsg{report, err}
+	}
+}
+
+// openReconcile starts a scan of apps, remote state and all clusters.
+func openReconcile(m model) (model, tea.Cmd) {
+	m.reconcile = reconcileReport{}
+	m.reconcileConfirm = false
+	m.reconcileTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Kind", Width: 10},
+			{Title: "Name", Width: 34},
+			{Title: "Cluster", Width: 12},
+			{Title: "Detail", Width: uiWidth - 70},
+		}),
+		table.WithFocused(true),
+	)
+	m.reconcileTable.SetHeight(22)
+	m.statusMessage = "Scanning apps, remote state and clusters..."
+	return m.withScene(sceneReconcile), reconcileCmd(m.cfg, clusterOptions)
+}
+
+func updateReconcile(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case reconcileMsg:
+		if msg.err != nil {
+			m.statusMessage = "Reconcile failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.reconcile = msg.report
+		rows := make([]table.Row, len(msg.report.Orphans))
+		for i, o := range msg.report.Orphans {
+			rows[i] = table.Row{o.Kind, o.Name, o.Cluster, o.Detail}
+		}
+		m.reconcileTable.SetRows(rows)
+		m.reconcileTable.SetCursor(0)
+		m.statusMessage = fmt.Sprintf("%d orphan(s) found.", len(rows))
+		return m, nil
+	case orphanActionMsg:
+		if msg.err != nil {
+			m.statusMessage = msg.err.Error()
+			return m, nil
+		}
+		m = reloadDeployments(m)
+		m, cmd := openReconcile(m)
+		m.statusMessage = msg.result + " Rescanning..."
+		return m, cmd
+	case tea.KeyMsg:
+		idx := m.reconcileTable.Cursor()
+		valid := idx >= 0 && idx < len(m.reconcile.Orphans)
+		if m.reconcileConfirm {
+			m.reconcileConfirm = false
+			if (msg.String() == "y" || msg.String() == "Y") && valid {
+				cfg, o := m.cfg, m.reconcile.Orphans[idx]
+				m.statusMessage = fmt.Sprintf("Cleaning up %s '%s'...", o.Kind, o.Name)
+				return m, func() tea.Msg {
+					result, err := cleanupOrphan(cfg, o)
+					note := auditOutcome(cfg, "cleanup", o.Name, result, err)
+					return orphanActionMsg{result + note, err}
+				}
+			}
+			m.statusMessage = "Clean up canceled."
+			return m, nil
+		}
+		switch msg.String() {
+		case "esc", "q":
+			m.statusMessage = ""
+			return m.withScene(sceneLauncher), nil
+		case "r", "R":
+			return openReconcile(m)
+		case "c", "C":
+			if valid {
+				o := m.reconcile.Orphans[idx]
+				if o.Kind != orphanState && o.Kind != orphanDirectory {
+					m.statusMessage = fmt.Sprintf("%s orphans can't be cleaned up from the launcher.", o.Kind)
+					return m, nil
+				}
+				m.reconcileConfirm = true
+				m.statusMessage = fmt.Sprintf("Move %s of '%s' to the trash? [Y/N]", o.Kind, o.Name)
+			}
+			return m, nil
+		case "a", "A":
+			if !valid {
+				return m, nil
+			}
+			o := m.reconcile.Orphans[idx]
+			if o.Kind == orphanVM {
+				m, cmd := openImport(m, o.Cluster)
+				m.importSelected[o.VM.VmID] = true
+				return m, cmd
+			}
+			cfg := m.cfg
+			return m, func() tea.Msg {
+				result, err := adoptOrphan(cfg, o)
+				note := auditOutcome(cfg, "adopt", o.Name, result, err)
+				return orphanActionMsg{result + note, err}
+			}
+		}
+	}
+	var cmd tea.Cmd
+	m.reconcileTable, cmd = m.reconcileTable.Update(msg)
+	return m, cmd
+}
+
+// vmStatusMsg carries the live status of a deployment's VMs.
+type vmStatusMsg struct {
+	path     string
+	statuses []VMStatus
+	err      error
+}
+
+// vmMetricsMsg carries the last hour of metrics of a deployment's VMs.
+type vmMetricsMsg struct {
+	path    string
+	metrics []VMMetrics
+	err     error
+}
+
+// metricsTickMsg triggers the periodic refresh of the details panel.
+type metricsTickMsg time.Time
+
+func metricsTickCmd(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg { return metricsTickMsg(t) })
+}
+
+func fetchVMMetricsCmd(dep deploymentInfo) tea.Cmd {
+	return func() tea.Msg {
+		metrics, err := fetchVMMetrics(dep)
+		return vmMetricsMsg{dep.Path, metrics, err}
+	}
+}
+
+// powerDoneMsg reports the end of a power action.
+type powerDoneMsg struct {
+	result string
+	err    error
+}
+
+func fetchVMStatusCmd(dep deploymentInfo) tea.Cmd {
+	return func() tea.Msg {
+		statuses, err := fetchVMStatuses(dep)
+		return vmStatusMsg{dep.Path, statuses, err}
+	}
+}
+
+func vmRows(statuses []VMStatus) []table.Row {
+	rows := make([]table.Row, len(statuses))
+	for i, st := range statuses {
+		rows[i] = table.Row{
+			fmt.Sprintf("%d", st.VmID), st.Name, st.Node, st.Status, formatUptime(st.Uptime),
+			fmt.Sprintf("%.0f%% of %d", st.CPU*100, st.CPUs),
+			fmt.Sprintf("%s/%s GiB", formatGiB(st.Mem), formatGiB(st.MaxMem)),
+		}
+	}
+	return rows
+}
+
+// openVMs shows the VMs of dep and refreshes their status.
+func openVMs(m model, dep deploymentInfo) (model, tea.Cmd) {
+	m.vmDeployment = dep
+	m.pendingPower = ""
+	m.powerAll = false
+	m.vmTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: "VMID", Width: 8},
+			{Title: "Name", Width: 32},
+			{Title: "Node", Width: 14},
+			{Title: "Status", Width: 10},
+			{Title: "Uptime", Width: 10},
+			{Title: "CPU", Width: 14},
+			{Title: "Memory", Width: 18},
+		}),
+		table.WithRows(vmRows(m.vmStatuses[dep.Path])),
+		table.WithFocused(true),
+	)
+	m.vmTable.SetHeight(20)
+	m.statusMessage = "Refreshing VM status..."
+	return m.withScene(sceneVMs), fetchVMStatusCmd(dep)
+}
+
+// powerTargets returns the VMs a power action applies to.
+func powerTargets(m model) []VMStatus {
+	statuses := m.vmStatuses[m.vmDeployment.Path]
+	if m.powerAll {
+		return statuses
+	}
+	if idx := m.vmTable.Cursor(); idx >= 0 && idx < len(statuses) {
+		return statuses[idx : idx+1]
+	}
+	return nil
+}
+
+func updateVMs(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.pendingPower != "" {
+			action := m.pendingPower
+			m.pendingPower = ""
+			if msg.String() != "y" && msg.String() != "Y" {
+				m.statusMessage = action + " canceled."
+				return m, nil
+			}
+			targets, dep, cfg := powerTargets(m), m.vmDeployment, m.cfg
+			m.powerRunning = true
+			m.statusMessage = fmt.Sprintf("Running %s on %d VM(s)...", action, len(targets))
+			return m, func() tea.Msg {
+				result, err := powerVMs(dep, targets, action)
+				return powerDoneMsg{result + auditOutcome(cfg, action, dep.Name, result, err), err}
+			}
+		}
+		key := strings.ToLower(msg.String())
+		switch key {
+		case "esc", "q":
+			m.statusMessage = ""
+			return m.withScene(sceneLauncher), nil
+		case "tab":
+			m.powerAll = !m.powerAll
+			return m, nil
+		case "r":
+			m.statusMessage = "Refreshing VM status..."
+			return m, fetchVMStatusCmd(m.vmDeployment)
+		}
+		if action, ok := powerActions[key]; ok {
+			if m.powerRunning {
+				m.statusMessage = "A power action is still running."
+				return m, nil
+			}
+			targets := powerTargets(m)
+			if len(targets) == 0 {
+				m.statusMessage = "No VM to " + action + "."
+				return m, nil
+			}
+			names := make([]string, len(targets))
+			for i, t := range targets {
+				names[i] = fmt.Sprintf("%s (%d)", t.Name, t.VmID)
+			}
+			m.pendingPower = action
+			m.statusMessage = fmt.Sprintf("%s %s? [Y/N]", action, strings.Join(names, ", "))
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.vmTable, cmd = m.vmTable.Update(msg)
+	return m, cmd
+}
+
+// snapshotOpMsg reports the end of a snapshot, rollback or delete.
+type snapshotOpMsg struct {
+	result string
+	err    error
+}
+
+// openSnapshots lists the recorded snapshots of m.snapshotDeployment.
+func openSnapshots(m model) model {
+	snaps, err := listSnapshots(m.snapshotDeployment.Path)
+	if err != nil {
+		m.statusMessage = "Could not list snapshots: " + err.Error()
+	}
+	rows := make([]table.Row, len(snaps))
+	for i, s := range snaps {
+		ids := make([]string, len(s.VMIDs))
+		for j, id := range s.VMIDs {
+			ids[j] = fmt.Sprintf("%d", id)
+		}
+		rows[i] = table.Row{s.Name, s.CreatedAt, s.Action, strings.Join(ids, ", ")}
+	}
+	m.snapshots = snaps
+	m.pendingSnapshotOp = ""
+	m.snapshotTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Snapshot", Width: 32},
+			{Title: "Created", Width: 22},
+			{Title: "Action", Width: 10},
+			{Title: "VMs", Width: uiWidth - 80},
+		}),
+		table.WithRows(rows),
+		table.WithFocused(true),
+	)
+	m.snapshotTable.SetHeight(20)
+	if err == nil && m.statusMessage == "" {
+		m.statusMessage = fmt.Sprintf("%d snapshot(s).", len(snaps))
+	}
+	return m.withScene(sceneSnapshots)
+}
+
+// runSnapshotOp runs a snapshot operation in the background.
+func runSnapshotOp(m model, action, status string, op func() (string, error)) (model, tea.Cmd) {
+	m.snapshotRunning = true
+	m.statusMessage = status
+	cfg, name := m.cfg, m.snapshotDeployment.Name
+	return m, func() tea.Msg {
+		result, err := op()
+		return snapshotOpMsg{result + auditOutcome(cfg, action, name, result, err), err}
+	}
+}
+
+func updateSnapshots(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	dep := m.snapshotDeployment
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		idx := m.snapshotTable.Cursor()
+		if m.pendingSnapshotOp != "" {
+			op := m.pendingSnapshotOp
+			m.pendingSnapshotOp = ""
+			if (msg.String() != "y" && msg.String() != "Y") || idx < 0 || idx >= len(m.snapshots) {
+				m.statusMessage = op + " canceled."
+				return m, nil
+			}
+			rec := m.snapshots[idx]
+			if op == "rollback" {
+				return runSnapshotOp(m, "rollback", fmt.Sprintf("Rolling back to %s...", rec.Name), func() (string, error) {
+					return rollbackDeployment(dep, rec)
+				})
+			}
+			return runSnapshotOp(m, "delete-snapshot", fmt.Sprintf("Deleting %s...", rec.Name), func() (string, error) {
+				return deleteDeploymentSnapshot(dep, rec)
+			})
+		}
+		switch msg.String() {
+		case "esc", "q":
+			m.statusMessage = ""
+			return m.withScene(sceneLauncher), nil
+		}
+		if m.snapshotRunning {
+			break
+		}
+		switch msg.String() {
+		case "n", "N":
+			return runSnapshotOp(m, "snapshot", "Taking snapshot...", func() (string, error) {
+				rec, err := snapshotDeployment(dep, "manual")
+				return fmt.Sprintf("Snapshot %s taken.", rec.Name), err
+			})
+		case "b", "B", "x", "X":
+			if idx < 0 || idx >= len(m.snapshots) {
+				return m, nil
+			}
+			m.pendingSnapshotOp = "delete"
+			if strings.ToLower(msg.String()) == "b" {
+				m.pendingSnapshotOp = "rollback"
+				m.statusMessage = fmt.Sprintf("Roll back all VMs of '%s' and its tfvars to %s? Changes since then are lost. [Y/N]", dep.Name, m.snapshots[idx].Name)
+			} else {
+				m.statusMessage = fmt.Sprintf("Delete snapshot %s from all VMs? [Y/N]", m.snapshots[idx].Name)
+			}
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.snapshotTable, cmd = m.snapshotTable.Update(msg)
+	return m, cmd
+}
+
+// openHistory lists the tfvars versions of m.historyDeployment.
+func openHistory(m model) model {
+	entries, err := readHistory(m.historyDeployment)
+	if err != nil {
+		m.statusMessage = "Could not read history: " + err.Error()
+	}
+	m.history = make([]HistoryEntry, len(entries))
+	for i, e := range entries {
+		m.history[len(entries)-1-i] = e
+	}
+	m.historyMark = 0
+	m.pendingRevert = false
+	m.historyTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: " ", Width: 2},
+			{Title: "Version", Width: 8},
+			{Title: "Time", Width: 22},
+			{Title: "User", Width: 16},
+			{Title: "Action", Width: 10},
+			{Title: "Applied", Width: 8},
+		}),
+		table.WithFocused(true),
+	)
+	m.historyTable.SetHeight(9)
+	m = refreshHistoryRows(m)
+	m.historyView = viewport.New(uiWidth-8, 14)
+	m.historyView.SetContent("Enter shows the diff of a version against the current terraform.tfvars.")
+	if err == nil && m.statusMessage == "" {
+		m.statusMessage = fmt.Sprintf("%d version(s).", len(entries))
+	}
+	return m.withScene(sceneHistory)
+}
+
+func refreshHistoryRows(m model) model {
+	rows := make([]table.Row, len(m.history))
+	for i, e := range m.history {
+		mark, applied := "", ""
+		if e.Version == m.historyMark {
+			mark = "*"
+		}
+		if e.Applied {
+			applied = "✓"
+		}
+		rows[i] = table.Row{mark, fmt.Sprintf("v%d", e.Version), e.Time, e.User, e.Action, applied}
+	}
+	m.historyTable.SetRows(rows)
+	return m
+}
+
+// showHistoryDiff renders a diff into the history viewport; nil is the current file.
+func showHistoryDiff(m model, from, to *HistoryEntry) model {
+	diff, err := historyDiff(m.historyDeployment, from, to)
+	if err != nil {
+		m.statusMessage = "Diff failed: " + err.Error()
+		return m
+	}
+	m.historyView.SetContent(colorizeDiff(diff))
+	m.historyView.GotoTop()
+	return m
+}
+
+func updateHistory(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	idx := m.historyTable.Cursor()
+	valid := idx >= 0 && idx < len(m.history)
+	if m.pendingRevert {
+		m.pendingRevert = false
+		if (key.String() != "y" && key.String() != "Y") || !valid {
+			m.statusMessage = "Revert canceled."
+			return m, nil
+		}
+		v := m.history[idx].Version
+		name := filepath.Base(m.historyDeployment)
+		zone, err := historyZone(m.historyDeployment, m.history[idx])
+		if err == nil {
+			err = checkZoneChange(m.cfg, m.historyDeployment, zone)
+		}
+		if err != nil {
+			m.statusMessage = "Refused: " + err.Error()
+			m.statusMessage += recordAudit(m.cfg, AuditEntry{Action: "revert", Deployment: name, Result: auditDenied, Detail: err.Error()})
+			return m, nil
+		}
+		if err := revertTfvars(m.historyDeployment, m.history[idx]); err != nil {
+			m.statusMessage = "Revert failed: " + err.Error() + auditOutcome(m.cfg, "revert", name, "", err)
+			return m, nil
+		}
+		m.statusMessage = fmt.Sprintf("Reverted terraform.tfvars to v%d. Review and apply the deployment.", v)
+		m.statusMessage += auditOutcome(m.cfg, "revert", name, fmt.Sprintf("Reverted to v%d.", v), nil)
+		return openHistory(m), nil
+	}
+	switch key.String() {
+	case "esc", "q":
+		m.statusMessage = ""
+		return m.withScene(sceneLauncher), nil
+	case "enter":
+		if !valid {
+			return m, nil
+		}
+		sel := m.history[idx]
+		for i := range m.history {
+			if m.historyMark != 0 && m.history[i].Version == m.historyMark && m.historyMark != sel.Version {
+				m.statusMessage = fmt.Sprintf("v%d → v%d", m.historyMark, sel.Version)
+				return showHistoryDiff(m, &m.history[i], &sel), nil
+			}
+		}
+		m.statusMessage = fmt.Sprintf("v%d → current", sel.Version)
+		return showHistoryDiff(m, &sel, nil), nil
+	case " ":
+		if valid {
+			if m.historyMark == m.history[idx].Version {
+				m.historyMark = 0
+			} else {
+				m.historyMark = m.history[idx].Version
+			}
+			m = refreshHistoryRows(m)
+		}
+		return m, nil
+	case "a", "A":
+		entries, _ := readHistory(m.historyDeployment)
+		applied, ok := lastApplied(entries)
+		if !ok {
+			m.statusMessage = "This deployment was never applied from the launcher."
+			return m, nil
+		}
+		m.statusMessage = fmt.Sprintf("last applied v%d → current", applied.Version)
+		return showHistoryDiff(m, &applied, nil), nil
+	case "r", "R":
+		if valid {
+			m.pendingRevert = true
+			m.statusMessage = fmt.Sprintf("Overwrite terraform.tfvars with v%d? [Y/N]", m.history[idx].Version)
+		}
+		return m, nil
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		m.historyView, cmd = m.historyView.Update(msg)
+		return m, cmd
+	}
+	var cmd tea.Cmd
+	m.historyTable, cmd = m.historyTable.Update(msg)
+	return m, cmd
+}
+
+// openUsage computes the requested resources of all deployments and shows
+// them grouped by the current dimension.
+func openUsage(m model) model {
+	rows, notDeployed, problems := collectUsage(m.cfg.Costs, m.allDeployments)
+	m.usageRows = rows
+	m.usageView = viewport.New(uiWidth-8, 12)
+	m = showUsageGroups(m)
+	t := totalUsage(rows)
+	m.statusMessage = fmt.Sprintf("%d deployment(s): %d VMs, %d vCPU, %s GiB memory, %.0f GiB disk, %s per month.",
+		len(rows), t.VMs, t.Cores, formatMemGB(t.MemoryMB), t.DiskGB, formatCost(m.cfg.Costs, t.Cost))
+	if len(notDeployed) > 0 {
+		m.statusMessage += fmt.Sprintf(" %d not deployed and not counted.", len(notDeployed))
+	}
+	if m.cfg.Costs == nil {
+		m.statusMessage += " Add a costs table to the config for cost estimates."
+	}
+	if len(problems) > 0 {
+		m.statusMessage += "\nSkipped: " + strings.Join(problems, "; ")
+	}
+	return m.withScene(sceneUsage)
+}
+
+// showUsageGroups fills the usage table for the current dimension.
+func showUsageGroups(m model) model {
+	m.usageGroups = groupUsage(m.usageRows, usageDimensions[m.usageDim])
+	rows := make([]table.Row, len(m.usageGroups))
+	for i, g := range m.usageGroups {
+		rows[i] = table.Row{g.Key, fmt.Sprintf("%d", len(g.Deployments)), fmt.Sprintf("%d", g.VMs), fmt.Sprintf("%d", g.Cores),
+			formatMemGB(g.MemoryMB), fmt.Sprintf("%.0f", g.DiskGB), formatCost(m.cfg.Costs, g.Cost)}
+	}
+	m.usageTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: strings.ToUpper(usageDimensions[m.usageDim][:1]) + usageDimensions[m.usageDim][1:], Width: 32},
+			{Title: "Deployments", Width: 12},
+			{Title: "VMs", Width: 8},
+			{Title: "vCPU", Width: 8},
+			{Title: "Memory GiB", Width: 12},
+			{Title: "Disk GiB", Width: 12},
+			{Title: "Cost/month", Width: 16},
+		}),
+		table.WithRows(rows),
+		table.WithFocused(true),
+	)
+	m.usageTable.SetHeight(10)
+	return showUsageDetail(m)
+}
+
+// showUsageDetail lists the deployments of the selected group.
+func showUsageDetail(m model) model {
+	idx := m.usageTable.Cursor()
+	if idx < 0 || idx >= len(m.usageGroups) {
+		m.usageView.SetContent("No deployments.")
+		return m
+	}
+	m.usageView.SetContent(usageDetail(m.cfg.Costs, m.usageGroups[idx]))
+	m.usageView.GotoTop()
+	return m
+}
+
+func updateUsage(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "esc", "q":
+		m.statusMessage = ""
+		return m.withScene(sceneLauncher), nil
+	case "tab":
+		m.usageDim = (m.usageDim + 1) % len(usageDimensions)
+		return showUsageGroups(m), nil
+	case "shift+tab":
+		m.usageDim = (m.usageDim - 1 + len(usageDimensions)) % len(usageDimensions)
+		return showUsageGroups(m), nil
+	case "up", "down", "k", "j":
+		var cmd tea.Cmd
+		m.usageTable, cmd = m.usageTable.Update(msg)
+		return showUsageDetail(m), cmd
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		m.usageView, cmd = m.usageView.Update(msg)
+		return m, cmd
+	case "r", "R":
+		m = reloadDeployments(m)
+		return openUsage(m), nil
+	}
+	return m, nil
+}
+
+// openAudit shows the audit log, newest entry first.
+func openAudit(m model) model {
+	entries, err := readAudit(auditLogPath(m.cfg))
+	m.auditEntries = make([]AuditEntry, len(entries))
+	rows := make([]table.Row, len(entries))
+	for i := range entries {
+		e := entries[len(entries)-1-i]
+		m.auditEntries[i] = e
+		rows[i] = table.Row{e.Time, e.User, e.Host, e.Action, e.Deployment, e.Result, e.Plan}
+	}
+	m.auditTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Time", Width: 20},
+			{Title: "User", Width: 12},
+			{Title: "Host", Width: 14},
+			{Title: "Action", Width: 14},
+			{Title: "Deployment", Width: 32},
+			{Title: "Result", Width: 9},
+			{Title: "Plan", Width: uiWidth - 129},
+		}),
+		table.WithRows(rows),
+		table.WithFocused(true),
+	)
+	m.auditTable.SetHeight(12)
+	m.auditView = viewport.New(uiWidth-8, 12)
+	m.statusMessage = fmt.Sprintf("%d audit entries.", len(entries))
+	if err != nil {
+		m.statusMessage = "Could not read the audit log: " + err.Error()
+	}
+	return showAuditEntry(m).withScene(sceneAudit)
+}
+
+// showAuditEntry shows the changes and detail of the selected entry.
+func showAuditEntry(m model) model {
+	idx := m.auditTable.Cursor()
+	if idx < 0 || idx >= len(m.auditEntries) {
+		m.auditView.SetContent("No audit entries.")
+		return m
+	}
+	e := m.auditEntries[idx]
+	var b strings.Builder
+	user := e.User
+	if e.DisplayUser != "" {
+		user = fmt.Sprintf("%s (as %s)", e.User, e.DisplayUser)
+	}
+	fmt.Fprintf(&b, "%s by %s@%s at %s: %s\n", e.Action, user, e.Host, e.Time, e.Result)
+	if e.Plan != "" {
+		fmt.Fprintf(&b, "Plan: %s\n", e.Plan)
+	}
+	if len(e.Changes) > 0 {
+		b.WriteString("\nChanges:\n")
+		for _, c := range e.Changes {
+			fmt.Fprintf(&b, "  %s: %q → %q\n", c.Field, c.Old, c.New)
+		}
+	}
+	if e.Detail != "" {
+		b.WriteString("\n" + e.Detail + "\n")
+	}
+	m.auditView.SetContent(b.String())
+	m.auditView.GotoTop()
+	return m
+}
+
+func updateAudit(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "esc", "q":
+		m.statusMessage = ""
+		return m.withScene(sceneLauncher), nil
+	case "up", "down", "k", "j":
+		var cmd tea.Cmd
+		m.auditTable, cmd = m.auditTable.Update(msg)
+		return showAuditEntry(m), cmd
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		m.auditView, cmd = m.auditView.Update(msg)
+		return m, cmd
+	case "r", "R":
+		return openAudit(m), nil
+	case "e", "E":
+		// Oldest first, like the log itself
+		entries := make([]AuditEntry, len(m.auditEntries))
+		for i, e := range m.auditEntries {
+			entries[len(entries)-1-i] = e
+		}
+		path, err := exportAudit(m.cfg, entries)
+		if err != nil {
+			m.statusMessage = "Export failed: " + err.Error()
+		} else {
+			m.statusMessage = fmt.Sprintf("%d entries exported to %s.", len(entries), path)
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+// openClone opens the create form pre-filled from a deployment's tfvars, with
+// its template and preset, and the fields that must be unique moved to the
+// next free values.
+func openClone(m model, src deploymentInfo) (model, tea.Cmd) {
+	meta, err := readDeploymentMeta(src.Path)
+	if err != nil {
+		m.statusMessage = "Could not read launcher.meta: " + err.Error()
+		return m, nil
+	}
+	tmplIdx := findTemplate(m.tfTemplates, meta.Template)
+	if tmplIdx < 0 {
+		tmplIdx = 0
+	}
+	values, err := cloneValues(m.cfg.AppsPath, m.tfTemplates[tmplIdx].Provider, src, m.allDeployments)
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Could not clone '%s': %v", src.Name, err)
+		return m, nil
+	}
+	for i, p := range m.presets {
+		if p.Name == meta.Preset {
+			m.presetIdx = i
+		}
+	}
+	m = selectTemplate(m, tmplIdx)
+	for i, label := range m.createLabels {
+		if v, ok := values[label]; ok {
+			m.createInputs[i].SetValue(v)
+		}
+	}
+	m.cloneSource = src.Name
+	m.statusMessage = ""
+	m.currentScene = sceneCreateForm
+	if cluster := values["cluster"]; cluster != "" && indexOf("vm_template", m.createLabels) >= 0 {
+		m.isFetchingTemplates = true
+		return m, fetchTemplatesCmd(cluster)
+	}
+	return m, nil
+}
+
+// applyPolicyDenial evaluates the policy against a deployment's tfvars before
+// they are applied or sent for approval, so versions restored from history or
+// a snapshot, or saved under an older policy, aren't applied unchecked. It
+// returns the message for a denial (which is audited), or "".
+func applyPolicyDenial(m model, deployDir, action string) string {
+	values, err := deploymentFormValues(deployDir)
+	if err != nil {
+		return "Could not evaluate the policy: " + err.Error()
+	}
+	denials := policyDenials(m.policy.Evaluate(values, osUser()))
+	if len(denials) == 0 {
+		return ""
+	}
+	return "Blocked by policy:\n" + formatViolations(denials) +
+		recordAudit(m.cfg, AuditEntry{Action: action, Deployment: filepath.Base(deployDir), Result: auditDenied, Detail: formatViolations(denials)})
+}
+
+// requestOrApplyApproved handles apply in an approval zone: an approved plan
+// is applied, otherwise the current tfvars are planned and put up for approval.
+func requestOrApplyApproved(m model, deployDir string) (model, tea.Cmd) {
+	tf, cfg := m.tf, m.cfg
+	req, err := readChangeRequest(deployDir)
+	if err != nil {
+		m.editStatus = "Could not read the change request: " + err.Error()
+		return m, nil
+	}
+	if req != nil && req.Status == requestApproved {
+		return m.startTerraform("apply-approved", deployDir, fmt.Sprintf("Applying the plan approved by %s...", req.ReviewedBy),
+			func(ctx context.Context) terraformDoneMsg {
+				return applyApproved(ctx, cfg, tf, deployDir)
+			})
+	}
+	if req != nil && req.Status == requestPending {
+		current, _ := os.ReadFile(filepath.Join(deployDir, "terraform.tfvars"))
+		proposed, _ := os.ReadFile(filepath.Join(pendingDir(deployDir), proposedTfvars))
+		if string(current) == string(proposed) {
+			m.editStatus = fmt.Sprintf("Request %s by %s is awaiting approval by another user.", req.ID, req.RequestedBy)
+			return m, nil
+		}
+	}
+	return m.startTerraform("request", deployDir, "Approval required: running terraform plan for the request...",
+		func(ctx context.Context) terraformDoneMsg {
+			result, plan, err := requestApproval(ctx, tf, deployDir, "apply")
+			return terraformDoneMsg{result: result, plan: plan, err: err}
+		})
+}
+
+// openApprovals lists the open change requests of all deployments.
+func openApprovals(m model) model {
+	m.approvals = listChangeRequests(m.allDeployments)
+	rows := make([]table.Row, len(m.approvals))
+	for i, c := range m.approvals {
+		rows[i] = table.Row{c.Dep.Name, c.Req.Action, c.Req.RequestedBy, c.Req.RequestedAt, c.Req.Status, c.Req.ReviewedBy}
+	}
+	m.approvalTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Deployment", Width: 32},
+			{Title: "Action", Width: 8},
+			{Title: "Requested by", Width: 14},
+			{Title: "Requested at", Width: 22},
+			{Title: "Status", Width: 10},
+			{Title: "Reviewed by", Width: 14},
+		}),
+		table.WithRows(rows),
+		table.WithFocused(true),
+	)
+	m.approvalTable.SetHeight(8)
+	m.approvalView = viewport.New(uiWidth-8, 16)
+	m.statusMessage = fmt.Sprintf("%d open request(s). You are %s.", len(m.approvals), osUser())
+	return showApproval(m).withScene(sceneApprovals)
+}
+
+// showApproval shows the review of the selected request.
+func showApproval(m model) model {
+	idx := m.approvalTable.Cursor()
+	if idx < 0 || idx >= len(m.approvals) {
+		m.approvalView.SetContent("No open change requests.")
+		return m
+	}
+	m.approvalView.SetContent(colorizePlan(colorizeDiff(changeRequestReview(m.approvals[idx]))))
+	m.approvalView.GotoTop()
+	return m
+}
+
+func updateApprovals(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	idx := m.approvalTable.Cursor()
+	valid := idx >= 0 && idx < len(m.approvals)
+	switch keyMsg.String() {
+	case "esc", "q":
+		return m.withScene(sceneLauncher), nil
+	case "up", "down", "k", "j":
+		var cmd tea.Cmd
+		m.approvalTable, cmd = m.approvalTable.Update(msg)
+		return showApproval(m), cmd
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		m.approvalView, cmd = m.approvalView.Update(msg)
+		return m, cmd
+	case "a", "A", "r", "R":
+		if !valid {
+			return m, nil
+		}
+		approve := keyMsg.String() == "a" || keyMsg.String() == "A"
+		action := "reject"
+		if approve {
+			action = "approve"
+		}
+		c := m.approvals[idx]
+		result, err := reviewChangeRequest(c.Dep.Path, approve)
+		m = reloadDeployments(m)
+		m = openApprovals(m)
+		m.statusMessage = result
+		if err != nil {
+			m.statusMessage = err.Error()
+		}
+		m.statusMessage += auditOutcome(m.cfg, action, c.Dep.Name, result, err)
+		return m, nil
+	case "g", "G":
+		if !valid {
+			return m, nil
+		}
+		c := m.approvals[idx]
+		if c.Req.Status != requestApproved {
+			m.statusMessage = fmt.Sprintf("Request %s is %s; only approved plans can be applied.", c.Req.ID, c.Req.Status)
+			return m, nil
+		}
+		tf, cfg, dir := m.tf, m.cfg, c.Dep.Path
+		if denial := applyPolicyDenial(m, dir, "apply"); denial != "" {
+			m.statusMessage = denial
+			return m, nil
+		}
+		return m.startTerraform("apply-approved", dir, fmt.Sprintf("Applying the plan for '%s' approved by %s...", c.Dep.Name, c.Req.ReviewedBy),
+			func(ctx context.Context) terraformDoneMsg {
+				return applyApproved(ctx, cfg, tf, dir)
+			})
+	}
+	return m, nil
+}
+
+// openMetadata opens the ownership form of a deployment.
+func openMetadata(m model, dep deploymentInfo) model {
+	meta, err := readDeploymentMeta(dep.Path)
+	if err != nil {
+		m.statusMessage = "Could not read launcher.meta: " + err.Error()
+		return m
+	}
+	values := metaFormValues(meta)
+	m.metaInputs = make([]textinput.Model, len(metaFormFields))
+	for i, f := range metaFormFields {
+		m.metaInputs[i] = textinput.New()
+		m.metaInputs[i].Placeholder = f.Key
+		m.metaInputs[i].SetValue(values[f.Key])
+	}
+	m.metaInputs[0].Focus()
+	m.metaFocus = 0
+	m.metaDeployment = dep
+	m.statusMessage = ""
+	m.currentScene = sceneMetadata
+	return m
+}
+
+func updateMetadata(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			return m.withScene(sceneLauncher), nil
+		case "tab", "down":
+			m.metaFocus = (m.metaFocus + 1) % len(m.metaInputs)
+		case "shift+tab", "up":
+			m.metaFocus = (m.metaFocus - 1 + len(m.metaInputs)) % len(m.metaInputs)
+		case "enter":
+			meta, err := readDeploymentMeta(m.metaDeployment.Path)
+			if err != nil {
+				m.statusMessage = "Could not read launcher.meta: " + err.Error()
+				return m, nil
+			}
+			values := make(map[string]string, len(metaFormFields))
+			keys := make([]string, len(metaFormFields))
+			for i, f := range metaFormFields {
+				values[f.Key] = m.metaInputs[i].Value()
+				keys[i] = f.Key
+			}
+			before := metaFormValues(meta)
+			if meta, err = applyMetaForm(meta, values); err != nil {
+				m.statusMessage = err.Error()
+				return m, nil
+			}
+			if err := writeDeploymentMeta(m.metaDeployment.Path, meta); err != nil {
+				m.statusMessage = "Failed to write launcher.meta: " + err.Error()
+				return m, nil
+			}
+			m = reloadDeployments(m).withScene(sceneLauncher)
+			m.statusMessage = fmt.Sprintf("Metadata of '%s' saved.", m.metaDeployment.Name)
+			if changes := mapChanges(keys, before, metaFormValues(meta)); len(changes) > 0 {
+				m.statusMessage += auditOutcome(m.cfg, "metadata", m.metaDeployment.Name, "", nil, changes...)
+			}
+			return m, nil
+		default:
+			var cmd tea.Cmd
+			m.metaInputs[m.metaFocus], cmd = m.metaInputs[m.metaFocus].Update(msg)
+			return m, cmd
+		}
+		for i := range m.metaInputs {
+			if i == m.metaFocus {
+				m.metaInputs[i].Focus()
+			} else {
+				m.metaInputs[i].Blur()
+			}
+		}
+	}
+	return m, nil
+}
+
+// openRename opens the rename form with the deployment's current zone and platform ID.
+func openRename(m model, dep deploymentInfo) model {
+	vals, err := loadTfvars(filepath.Join(dep.Path, "terraform.tfvars"))
+	if err != nil {
+		m.statusMessage = "Could not load tfvars: " + err.Error()
+		return m
+	}
+	m.renameDeployment = dep
+	m.renameProvider, _, _ = strings.Cut(dep.Name, "_")
+	if meta, err := readDeploymentMeta(dep.Path); err == nil {
+		if i := findTemplate(m.tfTemplates, meta.Template); i >= 0 {
+			m.renameProvider = m.tfTemplates[i].Provider
+		}
+	}
+	m.renameZone = tfvarsFormValue(vals["zone"])
+	m.renamePlatformID = textinput.New()
+	m.renamePlatformID.SetValue(tfvarsFormValue(vals["platform_id"]))
+	m.renameConfirm = false
+	m.statusMessage = "Change the zone or platform ID; the app dir and state key follow."
+	m.currentScene = sceneRename
+	return m
+}
+
+func updateRename(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	req := renameRequest{Zone: m.renameZone, PlatformID: strings.TrimSpace(m.renamePlatformID.Value())}
+	if m.renameConfirm {
+		m.renameConfirm = false
+		if keyMsg.String() != "y" && keyMsg.String() != "Y" {
+			m.statusMessage = "Rename cancelled."
+			return m, nil
+		}
+		target, err := renameTarget(m.renameDeployment, m.renameProvider, req)
+		if err != nil {
+			m.statusMessage = err.Error()
+			return m, nil
+		}
+		dep, tf, cfg := m.renameDeployment, m.tf, m.cfg
+		return m.withScene(sceneLauncher).startTerraform("rename", dep.Path,
+			fmt.Sprintf("Renaming '%s' to '%s' and migrating its state...", dep.Name, target),
+			func(ctx context.Context) terraformDoneMsg {
+				result, err := renameDeployment(ctx, tf, cfg, dep, target, req)
+				return terraformDoneMsg{result: result, err: err}
+			})
+	}
+	switch keyMsg.String() {
+	case "esc":
+		return m.withScene(sceneLauncher), nil
+	case "up", "down", "tab", "shift+tab":
+		if m.renamePlatformID.Focused() {
+			m.renamePlatformID.Blur()
+		} else {
+			m.renamePlatformID.Focus()
+		}
+		return m, nil
+	case "enter":
+		target, err := renameTarget(m.renameDeployment, m.renameProvider, req)
+		if err != nil {
+			m.statusMessage = err.Error()
+			return m, nil
+		}
+		if target == m.renameDeployment.Name {
+			m.statusMessage = "Nothing to rename; change the zone or platform ID."
+			return m, nil
+		}
+		if _, err := os.Stat(filepath.Join(m.cfg.AppsPath, target)); err == nil {
+			m.statusMessage = fmt.Sprintf("'%s' already exists.", target)
+			return m, nil
+		}
+		if err := checkRenameApproval(m.cfg, m.renameDeployment, req); err != nil {
+			m.statusMessage = "Refused: " + err.Error()
+			m.statusMessage += recordAudit(m.cfg, AuditEntry{Action: "rename", Deployment: m.renameDeployment.Name, Result: auditDenied, Detail: err.Error()})
+			return m, nil
+		}
+		violations, err := renameViolations(m.policy, m.renameDeployment, req)
+		if err != nil {
+			m.statusMessage = "Could not evaluate the policy: " + err.Error()
+			return m, nil
+		}
+		if denials := policyDenials(violations); len(denials) > 0 {
+			m.statusMessage = "Blocked by policy:\n" + formatViolations(denials)
+			m.statusMessage += recordAudit(m.cfg, AuditEntry{Action: "rename", Deployment: m.renameDeployment.Name, Result: auditDenied, Detail: formatViolations(denials)})
+			return m, nil
+		}
+		m.renameConfirm = true
+		m.statusMessage = fmt.Sprintf("Rename '%s' to '%s' and migrate its remote state?", m.renameDeployment.Name, target)
+		if len(violations) > 0 {
+			m.statusMessage = formatViolations(violations) + "\n" + m.statusMessage
+		}
+		return m, nil
+	}
+	if !m.renamePlatformID.Focused() {
+		switch keyMsg.String() {
+		case "left":
+			m.renameZone = cycleOption(m.renameZone, zoneOptions, -1)
+		case "right", " ":
+			m.renameZone = cycleOption(m.renameZone, zoneOptions, +1)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.renamePlatformID, cmd = m.renamePlatformID.Update(msg)
+	return m, cmd
+}
+
+// openLogList lists the saved terraform runs of m.logDeployment.
+func openLogList(m model) model {
+	runs, err := listRunLogs(runLogDir(m.cfg.LogPath, m.logDeployment))
+	if err != nil {
+		m.statusMessage = "Could not list run logs: " + err.Error()
+		return m
+	}
+	rows := make([]table.Row, len(runs))
+	for i, run := range runs {
+		rows[i] = table.Row{run.Time.Local().Format("2006-01-02 15:04:05"), run.Action, run.Result}
+	}
+	m.logRuns = runs
+	m.logTable = table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Time", Width: 20},
+			{Title: "Action", Width: 12},
+			{Title: "Result", Width: uiWidth - 50},
+		}),
+		table.WithRows(rows),
+		table.WithFocused(true),
+	)
+	m.logTable.SetHeight(24)
+	m.statusMessage = fmt.Sprintf("%d run(s) logged in %s", len(runs), runLogDir(m.cfg.LogPath, m.logDeployment))
+	return m.withScene(sceneLogList)
+}
+
+func updateLogList(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc", "q":
+			m.statusMessage = ""
+			return m.withScene(sceneLauncher), nil
+		case "enter":
+			idx := m.logTable.Cursor()
+			if idx < 0 || idx >= len(m.logRuns) {
+				return m, nil
+			}
+			data, err := os.ReadFile(m.logRuns[idx].Path)
+			if err != nil {
+				m.statusMessage = "Could not read log: " + err.Error()
+				return m, nil
+			}
+			m.logContent = strings.Split(string(data), "\n")
+			m.logView = viewport.New(uiWidth-4, 26)
+			m.logView.SetContent(string(data))
+			m.logMatches, m.logMatchIdx = nil, 0
+			m.logSearch = textinput.New()
+			m.logSearching = false
+			m.statusMessage = m.logRuns[idx].Result
+			return m.withScene(sceneLogView), nil
+		}
+	}
+	var cmd tea.Cmd
+	m.logTable, cmd = m.logTable.Update(msg)
+	return m, cmd
+}
+
+func updateLogView(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if m.logSearching {
+			switch msg.String() {
+			case "esc":
+				m.logSearching = false
+				return m, nil
+			case "enter":
+				m.logSearching = false
+				m.logMatches, m.logMatchIdx = findLogMatches(m.logContent, m.logSearch.Value()), 0
+				return showLogMatch(m), nil
+			}
+			var cmd tea.Cmd
+			m.logSearch, cmd = m.logSearch.Update(msg)
+			return m, cmd
+		}
+		switch msg.String() {
+		case "esc", "q":
+			return openLogList(m), nil
+		case "/":
+			m.logSearching = true
+			m.logSearch.Focus()
+			return m, nil
+		case "n":
+			if len(m.logMatches) > 0 {
+				m.logMatchIdx = (m.logMatchIdx + 1) % len(m.logMatches)
+			}
+			return showLogMatch(m), nil
+		case "N":
+			if len(m.logMatches) > 0 {
+				m.logMatchIdx = (m.logMatchIdx - 1 + len(m.logMatches)) % len(m.logMatches)
+			}
+			return showLogMatch(m), nil
+		}
+	}
+	var cmd tea.Cmd
+	m.logView, cmd = m.logView.Update(msg)
+	return m, cmd
+}
+
+// findLogMatches returns the lines containing query, ignoring case and ANSI codes.
+func findLogMatches(lines []string, query string) []int {
+	query = strings.ToLower(query)
+	if query == "" {
+		return nil
+	}
+	var matches []int
+	for i, l := range lines {
+		if strings.Contains(strings.ToLower(ansi.Strip(l)), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// showLogMatch highlights the current match and scrolls it into view.
+func showLogMatch(m model) model {
+	if len(m.logMatches) == 0 {
+		m.logView.SetContent(strings.Join(m.logContent, "\n"))
+		m.statusMessage = fmt.Sprintf("No matches for %q", m.logSearch.Value())
+		return m
+	}
+	line := m.logMatches[m.logMatchIdx]
+	lines := append([]string(nil), m.logContent...)
+	lines[line] = focusedStyle.Render(ansi.Strip(lines[line]))
+	m.logView.SetContent(strings.Join(lines, "\n"))
+	m.logView.SetYOffset(max(line-m.logView.Height/2, 0))
+	m.statusMessage = fmt.Sprintf("Match %d/%d for %q (line %d)", m.logMatchIdx+1, len(m.logMatches), m.logSearch.Value(), line+1)
+	return m
+}
+
+func (m model) withScene(s scene) model {
+	m.currentScene = s
+	return m
+}
+
+func buildEditFormInputs(tfvars map[string]string, fieldMeta map[string]FieldMeta, orderedFields []string) ([]textinput.Model, []string) {
+	var labels []string
+	for _, key := range orderedFields {
+		if !fieldMeta[key].ReadOnly {
+			labels = append(labels, key)
+		}
+	}
+	inputs := make([]textinput.Model, len(labels))
+	for i, key := range labels {
+		ti := textinput.New()
+		ti.Placeholder = key
 		val := tfvars[key]
 		val = strings.Trim(val, "\"[]")
 		ti.SetValue(val)
@@ -685,6 +2993,25 @@ func indexOf(label string, labels []string) int {
 	return -1
 }
 
+func indexOfInt(v int, values []int) int {
+	for i, x := range values {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Message type for when templates are fetched (async)
 type templatesFetchedMsg struct {
 	templates []string
@@ -699,29 +3026,229 @@ func fetchTemplatesCmd(cluster string) tea.Cmd {
 	}
 }
 
+// terraformDoneMsg reports the end of a background terraform operation.
+type terraformDoneMsg struct {
+	action    string // create, apply, apply-approved, request, destroy, plan-destroy, plan-edit, output, import, rename
+	path      string
+	result    string
+	plan      PlanSummary
+	err       error
+	cancelled bool
+	unlockErr error
+}
+
+// mutatingActions are recorded in launcher.state when they are cancelled.
+var mutatingActions = map[string]bool{"create": true, "apply": true, "apply-approved": true, "destroy": true, "import": true}
+
+// startTerraform marks the launcher busy and runs fn in the background with a
+// cancellable context. fn's message is completed with action and path.
+func (m model) startTerraform(action, path, status string, fn func(ctx context.Context) terraformDoneMsg) (model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.isBusy = true
+	m.cancelRun = cancel
+	if m.currentScene == sceneEditForm {
+		m.editStatus = status
+	} else {
+		m.statusMessage = status
+	}
+	cfg := m.cfg
+	return m, func() tea.Msg {
+		defer cancel()
+		msg := fn(ctx)
+		msg.action, msg.path = action, path
+		if ctx.Err() != nil {
+			msg.cancelled = true
+			msg.unlockErr = waitForStateUnlock(cfg, path, time.Minute)
+			if mutatingActions[action] {
+				_ = setDeploymentState(path, "CANCELLED", action)
+			}
+		}
+		return msg
+	}
+}
+
+// applyAndReadOutputs runs init and apply and caches the deployment's outputs.
+// Failing to record the history or read outputs doesn't fail the apply; both are
+// reported in result.
+func applyAndReadOutputs(ctx context.Context, tf TerraformRunner, dir string) terraformDoneMsg {
+	if err := runTerraformInitApply(ctx, tf, dir); err != nil {
+		return terraformDoneMsg{err: err}
+	}
+	var note string
+	if _, err := recordTfvars(dir, "apply", true); err != nil {
+		note += " History not recorded: " + err.Error()
+	}
+	if _, err := runTerraformOutput(ctx, tf, dir); err != nil {
+		note += " Outputs not refreshed: " + err.Error()
+	}
+	return terraformDoneMsg{result: note}
+}
+
+func handleTerraformDone(m model, msg terraformDoneMsg) (tea.Model, tea.Cmd) {
+	name := filepath.Base(msg.path)
+	auditNote := auditTerraformDone(m.cfg, msg)
+	if msg.cancelled {
+		m.statusMessage = fmt.Sprintf("terraform %s of '%s' cancelled; state lock released.", msg.action, name)
+		if msg.unlockErr != nil {
+			m.statusMessage = fmt.Sprintf("terraform %s of '%s' cancelled, but %v", msg.action, name, msg.unlockErr)
+		}
+		m.statusMessage += auditNote
+		m = reloadDeployments(m)
+		return m.withScene(sceneLauncher), nil
+	}
+	switch msg.action {
+	case "create":
+		if msg.err != nil {
+			m.statusMessage = msg.err.Error()
+		} else {
+			m.statusMessage = fmt.Sprintf("Deployment '%s' deployed and ready!", name) + msg.result
+		}
+		m = reloadDeployments(m)
+	case "apply":
+		if msg.err != nil {
+			m.editStatus = msg.err.Error()
+		} else {
+			m.editStatus = "Deployment applied and ready!" + msg.result
+		}
+		m.editStatus += auditNote
+	case "destroy":
+		if msg.err != nil {
+			m.statusMessage = "Destroy failed: " + msg.err.Error()
+		} else {
+			m.statusMessage = msg.result
+		}
+		m = reloadDeployments(m)
+	case "output":
+		if msg.err != nil {
+			m.statusMessage = msg.err.Error()
+		} else {
+			m.statusMessage = "Outputs refreshed."
+		}
+		m = openOutputs(m)
+	case "request":
+		if msg.err != nil {
+			m.statusMessage = "Approval request failed: " + msg.err.Error()
+		} else {
+			m.statusMessage = msg.result
+		}
+		m.editStatus = m.statusMessage
+		m = reloadDeployments(m)
+	case "apply-approved":
+		if msg.err != nil {
+			m.statusMessage = msg.err.Error()
+		} else {
+			m.statusMessage = msg.result
+		}
+		m.editStatus = m.statusMessage
+		m = reloadDeployments(m)
+		if m.currentScene == sceneApprovals {
+			status := m.statusMessage
+			m = openApprovals(m)
+			m.statusMessage = status
+		}
+	case "rename":
+		if msg.err != nil {
+			m.statusMessage = "Rename failed: " + msg.err.Error()
+		} else {
+			m.statusMessage = msg.result
+		}
+		m = reloadDeployments(m)
+	case "import":
+		if msg.err != nil {
+			m.statusMessage = "Import failed: " + msg.err.Error()
+		} else {
+			m.statusMessage = msg.result
+		}
+		m = reloadDeployments(m)
+	case "plan-edit":
+		if msg.err != nil {
+			m.editStatus = msg.err.Error()
+			m.editPlan = nil
+			return m, nil
+		}
+		plan := msg.plan
+		m.editPlan = &plan
+		content := renderPlanSummary(plan)
+		if warnings := impactWarnings(m.fieldMeta, editChanges(m), currentEditPlan(m)); len(warnings) > 0 {
+			content = strings.Join(warnings, "\n") + "\n\n" + content
+		}
+		m.editPlanView.SetContent(colorizePlan(content))
+		m.editStatus = fmt.Sprintf("Plan: %d to add, %d to change, %d to replace, %d to destroy.",
+			plan.Count("create"), plan.Count("update"), plan.Count("replace"), plan.Count("delete"))
+	case "plan-destroy":
+		if msg.err != nil {
+			m.statusMessage = "plan -destroy failed: " + msg.err.Error()
+			m.destroyPlanView.SetContent("")
+		} else {
+			m.statusMessage = fmt.Sprintf("plan -destroy: %d resource(s) will be destroyed.", msg.plan.Count("delete"))
+			m.destroyPlanView.SetContent(colorizePlan(renderPlanSummary(msg.plan)))
+		}
+	}
+	if msg.action != "apply" {
+		m.statusMessage += auditNote
+	}
+	return m, nil
+}
+
 type BusyFinishedMsg struct {
 	Success      bool
 	ErrorMessage string
 }
 
+// newCreateInputs builds empty create form inputs for the given fields.
+func newCreateInputs(labels []string) []textinput.Model {
+	inputs := make([]textinput.Model, len(labels))
+	for i, name := range labels {
+		ti := textinput.New()
+		ti.Placeholder = name
+		if name == "vm_template" {
+			ti.Width = 40
+		}
+		inputs[i] = ti
+	}
+	if len(inputs) > 0 {
+		inputs[0].Focus()
+	}
+	return inputs
+}
+
+// selectTemplate rebuilds the create form for the template at idx, keeping the current preset.
+func selectTemplate(m model, idx int) model {
+	m.tfTemplateIdx = idx
+	m.createLabels = m.tfTemplates[idx].Fields
+	m.createInputs = newCreateInputs(m.createLabels)
+	m.createFocus = 0
+	return applyPresetToForm(m, m.presetIdx)
+}
+
+// selectPreset applies the preset at idx, switching template first when the preset names one.
+func selectPreset(m model, idx int) model {
+	m.presetIdx = idx
+	if i := findTemplate(m.tfTemplates, m.presets[idx].Template); i >= 0 && i != m.tfTemplateIdx {
+		return selectTemplate(m, i)
+	}
+	return applyPresetToForm(m, idx)
+}
+
+// createValue returns the create form value for label, or "" if the template lacks it.
+func createValue(m model, label string) string {
+	if i := indexOf(label, m.createLabels); i >= 0 {
+		return m.createInputs[i].Value()
+	}
+	return ""
+}
+
+func fieldLabel(fieldMeta map[string]FieldMeta, key string) string {
+	if meta, ok := fieldMeta[key]; ok && meta.Label != "" {
+		return meta.Label
+	}
+	return key
+}
+
 func applyPresetToForm(m model, presetIdx int) model {
 	for i, label := range m.createLabels {
-		val, ok := m.presets[presetIdx].Values[label]
-		if ok {
-			switch v := val.(type) {
-			case string:
-				m.createInputs[i].SetValue(v)
-			case int:
-				m.createInputs[i].SetValue(fmt.Sprintf("%d", v))
-			case []interface{}:
-				strs := []string{}
-				for _, e := range v {
-					strs = append(strs, fmt.Sprintf("%v", e))
-				}
-				m.createInputs[i].SetValue(strings.Join(strs, ","))
-			default:
-				m.createInputs[i].SetValue(fmt.Sprintf("%v", v))
-			}
+		if val, ok := m.presets[presetIdx].FormValue(label); ok {
+			m.createInputs[i].SetValue(val)
 		}
 	}
 	return m
@@ -750,6 +3277,24 @@ func updateCreateForm(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		switch msg.String() {
+		case "f2":
+			return selectPreset(m, (m.presetIdx-1+len(m.presets))%len(m.presets)), nil
+		case "f3":
+			return selectPreset(m, (m.presetIdx+1)%len(m.presets)), nil
+		case "f4":
+			return selectTemplate(m, (m.tfTemplateIdx-1+len(m.tfTemplates))%len(m.tfTemplates)), nil
+		case "f5":
+			return selectTemplate(m, (m.tfTemplateIdx+1)%len(m.tfTemplates)), nil
+		case "f6", "f7":
+			dir := 1
+			if msg.String() == "f6" {
+				dir = -1
+			}
+			i := (indexOfInt(m.createTTL, ttlOptions) + dir + len(ttlOptions)) % len(ttlOptions)
+			m.createTTL = ttlOptions[i]
+			return m, nil
+		}
 		// Make these fields only cycle with left/right/space, block text input
 		if readonlyFields[curLabel] {
 			switch msg.String() {
@@ -797,14 +3342,6 @@ func updateCreateForm(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.createFocus = (m.createFocus + 1) % len(m.createInputs)
 			case "esc", "ctrl+c":
 				return m.withScene(sceneLauncher), nil
-			case "f2":
-				m.presetIdx = (m.presetIdx - 1 + len(m.presets)) % len(m.presets)
-				m = applyPresetToForm(m, m.presetIdx)
-				return m, nil
-			case "f3":
-				m.presetIdx = (m.presetIdx + 1) % len(m.presets)
-				m = applyPresetToForm(m, m.presetIdx)
-				return m, nil
 			case "enter":
 				// You may want to allow enter to submit even if focus is on a cycling field
 				break // let it fall through below
@@ -830,99 +3367,86 @@ func updateCreateForm(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Save/deploy logic (always allowed on Enter)
 		if msg.String() == "enter" {
-			provider := "proxmox"
-			app := m.createInputs[indexOf("vm_app", m.createLabels)].Value()
-			zone := m.createInputs[indexOf("zone", m.createLabels)].Value()
-			platformID := m.createInputs[indexOf("platform_id", m.createLabels)].Value()
-			appDir := fmt.Sprintf("%s_%s_%s_%s", provider, app, zone, platformID)
+			tmpl := m.tfTemplates[m.tfTemplateIdx]
+			app := createValue(m, "vm_app")
+			zone := createValue(m, "zone")
+			platformID := createValue(m, "platform_id")
+			appDir := fmt.Sprintf("%s_%s_%s_%s", tmpl.Provider, app, zone, platformID)
 			destPath := filepath.Join(m.cfg.AppsPath, appDir)
 
 			if _, err := os.Stat(destPath); err == nil {
 				m.statusMessage = fmt.Sprintf("Deployment '%s' already exists!", appDir)
 				return m, nil
 			}
-			if err := copyDir(m.cfg.TemplatePath, destPath); err != nil {
-				m.statusMessage = "Failed to copy template: " + err.Error()
-				return m, nil
-			}
-			updates := make(map[string]string)
-			stringFields := map[string]bool{
-				"platform_description": true,
-				"vm_app":               true,
-				"zone":                 true,
-				"cluster":              true,
-				"platform_id":          true,
-				"vm_template":          true,
-			}
+			values := make(map[string]string)
 			for i, key := range m.createLabels {
-				v := m.createInputs[i].Value()
-				if key == "vm_disk_size" {
-					arr := []string{}
-					for _, part := range strings.Split(v, ",") {
-						s := strings.Trim(strings.TrimSpace(part), "\"")
-						arr = append(arr, fmt.Sprintf("\"%s\"", s))
-					}
-					updates[key] = "[" + strings.Join(arr, ", ") + "]"
-				} else if stringFields[key] {
-					updates[key] = fmt.Sprintf("\"%s\"", v)
-				} else {
-					updates[key] = v
-				}
+				values[key] = m.createInputs[i].Value()
 			}
-			tfvarsPath := filepath.Join(destPath, "terraform.tfvars")
-			if err := saveTfvars(tfvarsPath, updates); err != nil {
-				m.statusMessage = "Failed to write tfvars: " + err.Error()
+			policyValues := map[string]string{"template": tmpl.Name}
+			for k, v := range values {
+				policyValues[k] = v
+			}
+			violations := m.policy.Evaluate(policyValues, osUser())
+			if denials := policyDenials(violations); len(denials) > 0 {
+				m.statusMessage = "Blocked by policy:\n" + formatViolations(denials)
+				m.statusMessage += recordAudit(m.cfg, AuditEntry{Action: "create", Deployment: appDir, Result: auditDenied, Detail: formatViolations(denials)})
 				return m, nil
 			}
-			regionLine := "ap-southeast-2"
-			if m.cfg.AWSRegion != "" {
-				regionLine = m.cfg.AWSRegion
-			}
-			profileLine := ""
-			if m.cfg.AWSProfile != "" {
-				profileLine = fmt.Sprintf("\n    profile         = \"%s\"", m.cfg.AWSProfile)
-			}
-			s3tf := fmt.Sprintf(
-				`terraform {
-  backend "s3" {
-    bucket          = "%s"
-    key             = "%s/s3/terraform.tfstate"
-    use_lockfile    = true
-    region          = "%s"
-    encrypt         = true%s
-  }
-}
-`, m.cfg.S3Bucket, appDir, regionLine, profileLine)
-			s3tfPath := filepath.Join(destPath, "s3.tf")
-			if err := os.WriteFile(s3tfPath, []byte(s3tf), 0644); err != nil {
-				m.statusMessage = "Failed to write s3.tf: " + err.Error()
+			if len(violations) > 0 && m.createAck != fmt.Sprint(policyValues) {
+				m.createAck = fmt.Sprint(policyValues)
+				m.statusMessage = formatViolations(violations) + "\nPress Enter again to create anyway."
 				return m, nil
 			}
-			if err := setDeploymentState(destPath, "READY", "save"); err != nil {
-				m.statusMessage = "Failed to write launcher.state: " + err.Error()
+			updates := formatTfvars(values)
+			data := TemplateData{
+				AppDir:  appDir,
+				Preset:  m.presets[m.presetIdx].Name,
+				Values:  values,
+				Vars:    updates,
+				Backend: newBackendConfig(m.cfg, appDir),
+			}
+			if err := renderTemplateDir(tmpl.Path, destPath, data); err != nil {
+				m.statusMessage = "Failed to render template: " + err.Error()
 				return m, nil
 			}
-			// Terraform actions
-			m.statusMessage = fmt.Sprintf("Deployment '%s' created. Running terraform init...", appDir)
-			if err := runTerraformInit(destPath); err != nil {
-				m.statusMessage = "terraform init failed: " + err.Error()
+			version, _ := templateVersion(tmpl.Path)
+			meta := DeploymentMeta{Template: tmpl.Name, TemplateSource: tmpl.Path, TemplateVersion: version, Preset: data.Preset,
+				ClonedFrom: m.cloneSource, Owner: currentUser(), Expires: expiryForTTL(m.createTTL, time.Now())}
+			if err := writeDeploymentMeta(destPath, meta); err != nil {
+				m.statusMessage = "Failed to write launcher.meta: " + err.Error()
 				return m, nil
 			}
-			if err := setDeploymentState(destPath, "INITIALIZED", "init"); err != nil {
-				m.statusMessage = "Failed to update launcher.state (init): " + err.Error()
+			// Templates without a terraform.tfvars.tmpl still get their existing keys patched
+			tfvarsPath := filepath.Join(destPath, "terraform.tfvars")
+			if err := saveTfvars(tfvarsPath, updates); err != nil {
+				m.statusMessage = "Failed to write tfvars: " + err.Error()
 				return m, nil
 			}
-			m.statusMessage = fmt.Sprintf("Deployment '%s' initialized. Running terraform apply...", appDir)
-			if err := runTerraformApply(destPath); err != nil {
-				m.statusMessage = "terraform apply failed: " + err.Error()
+			if _, err := recordTfvars(destPath, "create", false); err != nil {
+				m.statusMessage = "Failed to record tfvars history: " + err.Error()
 				return m, nil
 			}
-			if err := setDeploymentState(destPath, "DEPLOYED", "apply"); err != nil {
-				m.statusMessage = "Failed to update launcher.state (apply): " + err.Error()
+			if err := setDeploymentState(destPath, "READY", "save"); err != nil {
+				m.statusMessage = "Failed to write launcher.state: " + err.Error()
 				return m, nil
 			}
-			m.statusMessage = fmt.Sprintf("Deployment '%s' deployed and ready!", appDir)
-			return m.withScene(sceneLauncher), nil
+			// Terraform actions run in the background; the launcher shows progress
+			m.cloneSource = ""
+			m = reloadDeployments(m).withScene(sceneLauncher)
+			tf := m.tf
+			if requiresApproval(m.cfg, destPath) {
+				return m.startTerraform("request", destPath,
+					fmt.Sprintf("Deployment '%s' created in an approval zone. Running terraform plan for the approval request...", appDir),
+					func(ctx context.Context) terraformDoneMsg {
+						result, plan, err := requestApproval(ctx, tf, destPath, "create")
+						return terraformDoneMsg{result: result, plan: plan, err: err}
+					})
+			}
+			return m.startTerraform("create", destPath,
+				fmt.Sprintf("Deployment '%s' created. Running terraform init and apply...", appDir),
+				func(ctx context.Context) terraformDoneMsg {
+					return applyAndReadOutputs(ctx, tf, destPath)
+				})
 		}
 
 		// Focus/blur for all fields
@@ -940,7 +3464,10 @@ func updateCreateForm(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.templatesForCluster = nil
 		} else {
 			m.templatesForCluster = msg.templates
-			// Set template field to first available if not empty
+			// Set template field to first available if not empty, keeping a cloned template
+			if templateIdx >= 0 && indexOf(m.createInputs[templateIdx].Value(), msg.templates) >= 0 {
+				return m, nil
+			}
 			if templateIdx >= 0 && len(msg.templates) > 0 {
 				m.createInputs[templateIdx].SetValue(msg.templates[0])
 			} else if templateIdx >= 0 {
@@ -961,9 +3488,131 @@ func updateCreateForm(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // getEnvStatus now lives in internal_config.go
 
+// editChange is a field the edit form changed.
+type editChange struct {
+	Key, Old, New string
+}
+
+// editChanges lists the edit form fields that differ from the loaded values.
+func editChanges(m model) []editChange {
+	var changes []editChange
+	for i, key := range m.editFormLabels {
+		if v := m.editFormInputs[i].Value(); v != m.editOriginal[i] {
+			changes = append(changes, editChange{Key: key, Old: m.editOriginal[i], New: v})
+		}
+	}
+	return changes
+}
+
+// formatEditValue converts an edit form value to its tfvars representation.
+func formatEditValue(key, v string, meta FieldMeta) string {
+	if key == "vm_disk_size" {
+		return hclList(v)
+	}
+	if meta.Type == "string" {
+		return fmt.Sprintf("\"%s\"", v)
+	}
+	return v
+}
+
+// changesKey identifies a set of changes, to tell whether a plan still matches the form.
+func changesKey(changes []editChange) string {
+	return fmt.Sprint(changes)
+}
+
+// renderEditChanges summarises pending changes (old → new) with their change
+// hints and impact warnings, cross-checked against plan when there is one.
+func renderEditChanges(fieldMeta map[string]FieldMeta, changes []editChange, plan *PlanSummary) string {
+	lines := []string{"Save these changes?"}
+	for _, c := range changes {
+		lines = append(lines, fmt.Sprintf("  %-25s %s → %s", fieldLabel(fieldMeta, c.Key)+":", c.Old, c.New))
+		if hint := fieldMeta[c.Key].ChangeHint; hint != "" {
+			lines = append(lines, "    ⚠ "+hint)
+		}
+	}
+	lines = append(lines, impactWarnings(fieldMeta, changes, plan)...)
+	return strings.Join(lines, "\n")
+}
+
+// impactWarnings warns about changes that force replacement according to the
+// field metadata and, when a plan for exactly these changes is available,
+// reports where the plan disagrees with the metadata.
+func impactWarnings(fieldMeta map[string]FieldMeta, changes []editChange, plan *PlanSummary) []string {
+	var replacing []string
+	for _, c := range changes {
+		if fieldMeta[c.Key].Impact == impactReplace {
+			replacing = append(replacing, fieldLabel(fieldMeta, c.Key))
+		}
+	}
+	if plan == nil {
+		if len(replacing) > 0 {
+			return []string{fmt.Sprintf("⚠ %s forces replacement of the VMs (Ctrl+P to check the plan).", strings.Join(replacing, ", "))}
+		}
+		return nil
+	}
+	replaced := plan.Count("replace")
+	switch {
+	case replaced > 0 && len(replacing) > 0:
+		return []string{fmt.Sprintf("⚠ Plan replaces %d resource(s) because of %s.", replaced, strings.Join(replacing, ", "))}
+	case replaced > 0:
+		return []string{fmt.Sprintf("⚠ Plan replaces %d resource(s) although the changed fields are not marked as replacing.", replaced)}
+	case len(replacing) > 0:
+		return []string{fmt.Sprintf("Plan shows no replacement for %s.", strings.Join(replacing, ", "))}
+	}
+	return nil
+}
+
+// currentEditPlan returns the edit plan if it was made for the current changes.
+func currentEditPlan(m model) *PlanSummary {
+	if m.editPlan != nil && m.editPlanFor == changesKey(editChanges(m)) {
+		return m.editPlan
+	}
+	return nil
+}
+
+// saveEditChanges writes only the changed fields and records the new version.
+func saveEditChanges(m model, changes []editChange) model {
+	updates := make(map[string]string, len(changes))
+	for _, c := range changes {
+		updates[c.Key] = formatEditValue(c.Key, c.New, m.fieldMeta[c.Key])
+	}
+	deployment := filepath.Base(filepath.Dir(m.editFormPath))
+	if err := saveTfvars(m.editFormPath, updates); err != nil {
+		m.editStatus = "Save failed: " + err.Error() + auditOutcome(m.cfg, "edit", deployment, "", err, auditChanges(changes)...)
+		return m
+	}
+	for i := range m.editFormInputs {
+		m.editOriginal[i] = m.editFormInputs[i].Value()
+	}
+	if _, err := recordTfvars(filepath.Dir(m.editFormPath), "edit", false); err != nil {
+		m.editStatus = "Saved, but history not recorded: " + err.Error()
+	} else {
+		m.editStatus = fmt.Sprintf("Saved %d change(s)! (You may now apply changes as needed.)", len(changes))
+	}
+	m.editStatus += auditOutcome(m.cfg, "edit", deployment, fmt.Sprintf("Saved %d change(s).", len(changes)), nil, auditChanges(changes)...)
+	return m
+}
+
+// auditChanges converts edit form changes for the audit log.
+func auditChanges(changes []editChange) []AuditChange {
+	out := make([]AuditChange, len(changes))
+	for i, c := range changes {
+		out[i] = AuditChange{Field: c.Key, Old: c.Old, New: c.New}
+	}
+	return out
+}
+
 func updateEditForm(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.editConfirm {
+			m.editConfirm = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				return saveEditChanges(m, editChanges(m)), nil
+			}
+			m.editStatus = "Not saved."
+			return m, nil
+		}
 		curLabel := m.editFormLabels[m.editFocusIndex]
 		switch msg.String() {
 		case "esc", "q":
@@ -1001,51 +3650,73 @@ func updateEditForm(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.editFormInputs[m.editFocusIndex].SetValue(cycleOption(cur, clusterOptions, +1))
 			}
 		case "enter":
-			// Save tfvars only
-			updates := make(map[string]string)
-			for i, key := range m.editFormLabels {
-				v := m.editFormInputs[i].Value()
-				meta := m.fieldMeta[key]
-				if key == "vm_disk_size" {
-					arr := []string{}
-					for _, part := range strings.Split(v, ",") {
-						s := strings.Trim(strings.TrimSpace(part), "\"")
-						arr = append(arr, fmt.Sprintf("\"%s\"", s))
-					}
-					updates[key] = "[" + strings.Join(arr, ", ") + "]"
-				} else if meta.Type == "string" {
-					updates[key] = fmt.Sprintf("\"%s\"", v)
-				} else {
-					updates[key] = v
-				}
+			// Save tfvars only, after confirming what changed
+			changes := editChanges(m)
+			if len(changes) == 0 {
+				m.editStatus = "Nothing changed; terraform.tfvars not written."
+				return m, nil
 			}
-			if err := saveTfvars(m.editFormPath, updates); err != nil {
-				m.editStatus = "Save failed: " + err.Error()
-			} else {
-				m.editStatus = "Saved! (You may now apply changes as needed.)"
+			values, err := deploymentFormValues(filepath.Dir(m.editFormPath))
+			if err != nil {
+				m.editStatus = "Could not load tfvars: " + err.Error()
+				return m, nil
 			}
-			return m, nil
-		case "a": // [A] Apply
-			deployDir := filepath.Dir(m.editFormPath)
-			m.editStatus = "Running terraform apply..."
-			if err := runTerraformInit(deployDir); err != nil {
-				m.editStatus = "terraform init failed: " + err.Error()
+			for _, c := range changes {
+				values[c.Key] = c.New
+			}
+			if err := checkZoneChange(m.cfg, filepath.Dir(m.editFormPath), values["zone"]); err != nil {
+				m.editStatus = "Refused: " + err.Error()
+				m.editStatus += recordAudit(m.cfg, AuditEntry{Action: "edit", Deployment: filepath.Base(filepath.Dir(m.editFormPath)), Changes: auditChanges(changes), Result: auditDenied, Detail: err.Error()})
 				return m, nil
 			}
-			if err := setDeploymentState(deployDir, "INITIALIZED", "init"); err != nil {
-				m.editStatus = "Failed to update launcher.state (init): " + err.Error()
+			violations := m.policy.Evaluate(values, osUser())
+			if denials := policyDenials(violations); len(denials) > 0 {
+				m.editStatus = "Blocked by policy:\n" + formatViolations(denials)
+				m.editStatus += recordAudit(m.cfg, AuditEntry{Action: "edit", Deployment: filepath.Base(filepath.Dir(m.editFormPath)), Changes: auditChanges(changes), Result: auditDenied, Detail: formatViolations(denials)})
 				return m, nil
 			}
-			if err := runTerraformApply(deployDir); err != nil {
-				m.editStatus = "terraform apply failed: " + err.Error()
+			m.editConfirm = true
+			m.editStatus = renderEditChanges(m.fieldMeta, changes, currentEditPlan(m))
+			if len(violations) > 0 {
+				m.editStatus += "\n" + formatViolations(violations)
+			}
+			return m, nil
+		case "ctrl+p":
+			changes := editChanges(m)
+			vars := make(map[string]string, len(changes))
+			for _, c := range changes {
+				vars[c.Key] = formatEditValue(c.Key, c.New, m.fieldMeta[c.Key])
+			}
+			deployDir := filepath.Dir(m.editFormPath)
+			tf, key := m.tf, changesKey(changes)
+			m.editPlanFor = key
+			return m.startTerraform("plan-edit", deployDir, "Running terraform plan with the pending changes...",
+				func(ctx context.Context) terraformDoneMsg {
+					plan, err := runTerraformPlanWithVars(ctx, tf, deployDir, vars)
+					return terraformDoneMsg{plan: plan, err: err}
+				})
+		case "pgup", "pgdown":
+			var cmd tea.Cmd
+			m.editPlanView, cmd = m.editPlanView.Update(msg)
+			return m, cmd
+		case "a": // [A] Apply
+			if len(editChanges(m)) > 0 {
+				m.editStatus = "There are unsaved changes; press Enter to review and save them before applying."
 				return m, nil
 			}
-			if err := setDeploymentState(deployDir, "DEPLOYED", "apply"); err != nil {
-				m.editStatus = "Failed to update launcher.state (apply): " + err.Error()
+			deployDir := filepath.Dir(m.editFormPath)
+			tf, cfg := m.tf, m.cfg
+			if denial := applyPolicyDenial(m, deployDir, "apply"); denial != "" {
+				m.editStatus = denial
 				return m, nil
 			}
-			m.editStatus = "Deployment applied and ready!"
-			return m, nil
+			if requiresApproval(cfg, deployDir) {
+				return requestOrApplyApproved(m, deployDir)
+			}
+			return m.startTerraform("apply", deployDir, "Running terraform apply...",
+				func(ctx context.Context) terraformDoneMsg {
+					return snapshotAndApply(ctx, cfg, tf, deployDir)
+				})
 		}
 		for i := range m.editFormInputs {
 			if i == m.editFocusIndex {